@@ -12,32 +12,33 @@ var addCmd = &cobra.Command{
 	Short: "Adds free storage assigner",
 	Long:  "Adds free storage assigner",
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var err error
 		var flags = cmd.Flags()
 
 		name, err := flags.GetString("name")
 		if err != nil {
-			log.Fatal("invalid 'name' flag: ", err)
+			return usageError("invalid 'name' flag: ", err)
 		}
 		key, err := flags.GetString("key")
 		if err != nil {
-			log.Fatal("invalid 'key' flag: ", err)
+			return usageError("invalid 'key' flag: ", err)
 		}
 		limit, err := flags.GetFloat64("limit")
 		if err != nil {
-			log.Fatal("invalid 'limit' flag: ", err)
+			return usageError("invalid 'limit' flag: ", err)
 		}
 		max, err := flags.GetFloat64("max")
 		if err != nil {
-			log.Fatal("invalid 'max' flag: ", err)
+			return usageError("invalid 'max' flag: ", err)
 		}
 
 		err = sdk.AddFreeStorageAssigner(name, key, limit, max)
 		if err != nil {
-			log.Fatal("Error adding free storage assigner:", err)
+			return commandError("Error adding free storage assigner:", err)
 		}
 		log.Print(name + " added as free storage assigner")
+		return nil
 	},
 }
 
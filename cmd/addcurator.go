@@ -12,31 +12,32 @@ var addCuratorCmd = &cobra.Command{
 	Short: "Adds a curator to an allocation",
 	Long:  "Adds a curator to an allocation",
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var err error
 		var flags = cmd.Flags()
 
 		if flags.Changed("allocation") == false {
-			log.Fatal("Error: allocation flag is missing")
+			return usageError("Error: allocation flag is missing")
 		}
 		allocationID, err := flags.GetString("allocation")
 		if err != nil {
-			log.Fatal("invalid 'allocation_id' flag: ", err)
+			return usageError("invalid 'allocation_id' flag: ", err)
 		}
 
 		if flags.Changed("curator") == false {
-			log.Fatal("Error: curator flag is missing")
+			return usageError("Error: curator flag is missing")
 		}
 		curatorID, err := flags.GetString("curator")
 		if err != nil {
-			log.Fatal("invalid 'curator_id' flag: ", err)
+			return usageError("invalid 'curator_id' flag: ", err)
 		}
 
 		_, err = sdk.AddCurator(curatorID, allocationID)
 		if err != nil {
-			log.Fatal("Error adding curator:", err)
+			return commandError("Error adding curator:", err)
 		}
 		log.Print(curatorID + " added " + curatorID + " as a curator to allocation " + allocationID)
+		return nil
 	},
 }
 
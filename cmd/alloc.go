@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/0chain/zboxcli/util"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// files of the config directory, so each profile has its own default allocation and aliases
+const (
+	defaultAllocationFile = "allocation.txt"
+	allocationAliasesFile = "allocations.yaml"
+)
+
+var allocationIDRegexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// validateAllocationAlias checks an alias has the same form as a profile name, and can not be taken for an allocation id
+func validateAllocationAlias(name string) error {
+	if !profileNameRegexp.MatchString(name) || allocationIDRegexp.MatchString(name) {
+		return fmt.Errorf("invalid alias %q, use letters, digits, '_', '.' and '-'", name)
+	}
+	return nil
+}
+
+// defaultAllocation returns the allocation saved by newallocation or `alloc use`, empty if there is none
+func defaultAllocation() (string, error) {
+	configDir, err := resolveConfigDir()
+	if err != nil {
+		return "", err
+	}
+	buf, err := ioutil.ReadFile(filepath.Join(configDir, defaultAllocationFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// envOrDefaultAllocation returns the allocation or alias of ZBOX_ALLOCATION, or else the default allocation
+func envOrDefaultAllocation() (string, error) {
+	if v := os.Getenv(allocationEnv); len(v) > 0 {
+		return resolveAllocation(v)
+	}
+	return defaultAllocation()
+}
+
+func setDefaultAllocation(allocationID string) error {
+	configDir, err := resolveConfigDir()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(configDir, defaultAllocationFile), []byte(allocationID), 0644)
+}
+
+// loadAllocationAliases returns the aliases of allocation ids, by alias
+func loadAllocationAliases() (map[string]string, error) {
+	configDir, err := resolveConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	aliases := make(map[string]string)
+	buf, err := ioutil.ReadFile(filepath.Join(configDir, allocationAliasesFile))
+	if os.IsNotExist(err) {
+		return aliases, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(buf, &aliases); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", allocationAliasesFile, err)
+	}
+	return aliases, nil
+}
+
+func saveAllocationAliases(aliases map[string]string) error {
+	configDir, err := resolveConfigDir()
+	if err != nil {
+		return err
+	}
+	buf, err := yaml.Marshal(aliases)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(configDir, allocationAliasesFile), buf, 0644)
+}
+
+// resolveAllocation returns the allocation id of an alias, or v itself if it is not an alias
+func resolveAllocation(v string) (string, error) {
+	aliases, err := loadAllocationAliases()
+	if err != nil {
+		return "", err
+	}
+	if id, ok := aliases[v]; ok {
+		return id, nil
+	}
+	return v, nil
+}
+
+// annotationAllocationFilter marks commands whose --allocation only narrows what they show or do,
+// so they are not given the default allocation when the flag is not set
+const annotationAllocationFilter = "allocation_filter"
+
+// applyAllocationFlag resolves an alias given by the --allocation flag of cmd.
+// If the flag is not set, it is set to ZBOX_ALLOCATION or the default allocation, so commands find it as if it was given,
+// unless cmd is marked with annotationAllocationFilter.
+func applyAllocationFlag(cmd *cobra.Command) error {
+	flag := cmd.Flags().Lookup("allocation")
+	if flag == nil {
+		return nil
+	}
+
+	var allocationID string
+	var err error
+	if flag.Changed {
+		allocationID, err = resolveAllocation(flag.Value.String())
+	} else {
+		if cmd.Annotations[annotationAllocationFilter] == "true" {
+			return nil
+		}
+		allocationID, err = envOrDefaultAllocation()
+	}
+	if err != nil {
+		return configError("Error:", err)
+	}
+	if len(allocationID) == 0 {
+		return nil
+	}
+	return cmd.Flags().Set("allocation", allocationID)
+}
+
+var allocCmd = &cobra.Command{
+	Use:   "alloc",
+	Short: "Manage the default allocation and allocation aliases",
+	Long: `Manage the default allocation and allocation aliases.
+Commands which take --allocation use the default allocation when the flag is not set,
+except those where it only narrows what they show or do, like rp-info and upload-status,
+and accept an alias anywhere an allocation id is expected.
+The default allocation is saved in allocation.txt and the aliases in allocations.yaml of the config directory,
+so each profile has its own.`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+var allocUseCmd = &cobra.Command{
+	Use:         "use <id|alias>",
+	Short:       "Set the default allocation",
+	Long:        `Set the allocation used by commands run without --allocation`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		allocationID, err := resolveAllocation(args[0])
+		if err != nil {
+			return configError("Error:", err)
+		}
+		if !allocationIDRegexp.MatchString(allocationID) {
+			return notFoundError("Error: " + args[0] + " is not an allocation id or alias, see zbox alloc list")
+		}
+		if err = setDefaultAllocation(allocationID); err != nil {
+			return commandError("Error:", err)
+		}
+		fmt.Println("Default allocation " + allocationID)
+		return nil
+	},
+}
+
+var allocAliasCmd = &cobra.Command{
+	Use:         "alias <name> <id|alias>",
+	Short:       "Add or change an allocation alias",
+	Long:        `Add an alias of an allocation id, or change the allocation of an existing alias`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := validateAllocationAlias(name); err != nil {
+			return usageError("Error:", err)
+		}
+		aliases, err := loadAllocationAliases()
+		if err != nil {
+			return configError("Error:", err)
+		}
+		allocationID := args[1]
+		if id, ok := aliases[allocationID]; ok {
+			allocationID = id
+		}
+		if !allocationIDRegexp.MatchString(allocationID) {
+			return notFoundError("Error: " + args[1] + " is not an allocation id or alias, see zbox alloc list")
+		}
+		aliases[name] = allocationID
+		if err = saveAllocationAliases(aliases); err != nil {
+			return commandError("Error:", err)
+		}
+		fmt.Println("Alias " + name + " of allocation " + allocationID)
+		return nil
+	},
+}
+
+var allocUnaliasCmd = &cobra.Command{
+	Use:         "unalias <name>",
+	Short:       "Remove an allocation alias",
+	Long:        `Remove an allocation alias, the allocation itself is not changed`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		aliases, err := loadAllocationAliases()
+		if err != nil {
+			return configError("Error:", err)
+		}
+		if _, ok := aliases[name]; !ok {
+			return notFoundError("Error: alias " + name + " does not exist")
+		}
+		delete(aliases, name)
+		if err = saveAllocationAliases(aliases); err != nil {
+			return commandError("Error:", err)
+		}
+		fmt.Println("Alias " + name + " removed")
+		return nil
+	},
+}
+
+// allocationAlias is what `alloc list` prints of an alias
+type allocationAlias struct {
+	Alias        string `json:"alias"`
+	AllocationID string `json:"allocation_id"`
+	Default      bool   `json:"default"`
+}
+
+var allocListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List allocation aliases",
+	Long: `List allocation aliases and the default allocation, which is marked with *.
+A default allocation without an alias is listed without a name.`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		aliases, err := loadAllocationAliases()
+		if err != nil {
+			return configError("Error:", err)
+		}
+		defaultID, err := defaultAllocation()
+		if err != nil {
+			return configError("Error:", err)
+		}
+
+		names := make([]string, 0, len(aliases))
+		for name := range aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		list := make([]allocationAlias, 0, len(names)+1)
+		hasDefault := len(defaultID) == 0
+		for _, name := range names {
+			isDefault := aliases[name] == defaultID
+			hasDefault = hasDefault || isDefault
+			list = append(list, allocationAlias{Alias: name, AllocationID: aliases[name], Default: isDefault})
+		}
+		if !hasDefault {
+			list = append(list, allocationAlias{AllocationID: defaultID, Default: true})
+		}
+
+		data := make([][]string, 0, len(list))
+		for _, a := range list {
+			data = append(data, []string{a.Alias, a.AllocationID, fmt.Sprint(a.Default)})
+		}
+		printOutput(cmd, list, []string{"alias", "allocation_id", "default"}, data, func() {
+			rows := make([][]string, 0, len(list))
+			for _, a := range list {
+				mark := ""
+				if a.Default {
+					mark = "*"
+				}
+				alias := a.Alias
+				if len(alias) == 0 {
+					alias = "-"
+				}
+				rows = append(rows, []string{mark, alias, a.AllocationID})
+			}
+			util.WriteTable(os.Stdout, []string{"", "ALIAS", "ALLOCATION ID"}, []string{}, rows)
+		})
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(allocCmd)
+	allocCmd.AddCommand(allocUseCmd)
+	allocCmd.AddCommand(allocAliasCmd)
+	allocCmd.AddCommand(allocUnaliasCmd)
+	allocCmd.AddCommand(allocListCmd)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const testDefaultAllocation = "8695b9e7f986d4a447b64de020ba86f53b3b5e2c442abceb6cd65742702067dc"
+
+// runWithConfigDir runs the command of args with configDir as --configDir, and returns the command and its error.
+// The flags the command parsed are reset once the test is done.
+func runWithConfigDir(t *testing.T, configDir string, args ...string) (*cobra.Command, error) {
+	cmd, _, err := rootCmd.Find(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if f.Changed {
+				f.Value.Set(f.DefValue)
+				f.Changed = false
+			}
+		})
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+		cDir = ""
+		commandStarted = false
+	})
+	rootCmd.SetOut(ioutil.Discard)
+	rootCmd.SetArgs(append(args, "--configDir", configDir))
+	return cmd, rootCmd.Execute()
+}
+
+func TestDefaultAllocationFillsFlag(t *testing.T) {
+	configDir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(configDir, defaultAllocationFile), []byte(testDefaultAllocation+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"list", "--remotepath", "/"}, testDefaultAllocation},
+		{[]string{"upload", "--localpath", "a.txt", "--remotepath", "/a.txt"}, testDefaultAllocation},
+		{[]string{"cp-info"}, testDefaultAllocation},
+		{[]string{"alloc-fini"}, testDefaultAllocation},
+		// --allocation of rp-info only filters the pools it lists
+		{[]string{"rp-info"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			// the commands fail without config.yaml, after --allocation is applied
+			cmd, _ := runWithConfigDir(t, configDir, tt.args...)
+			flag := cmd.Flags().Lookup("allocation")
+			if got := flag.Value.String(); got != tt.want {
+				t.Errorf("--allocation is %q, want %q", got, tt.want)
+			}
+			if flag.Changed != (len(tt.want) > 0) {
+				t.Errorf("--allocation changed is %v", flag.Changed)
+			}
+		})
+	}
+}
+
+func TestAllocationAliasIsResolved(t *testing.T) {
+	configDir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(configDir, allocationAliasesFile), []byte("photos: "+testDefaultAllocation+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd, _ := runWithConfigDir(t, configDir, "list", "--allocation", "photos", "--remotepath", "/")
+	if got := cmd.Flag("allocation").Value.String(); got != testDefaultAllocation {
+		t.Errorf("--allocation photos is %q, want %q", got, testDefaultAllocation)
+	}
+}
+
+func TestAllocationEnvFillsFlag(t *testing.T) {
+	configDir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(configDir, defaultAllocationFile), []byte("ignored"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(configDir, allocationAliasesFile), []byte("photos: "+testDefaultAllocation+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(allocationEnv)
+
+	// ZBOX_ALLOCATION wins over the default allocation, and can be an alias
+	for _, v := range []string{testDefaultAllocation, "photos"} {
+		os.Setenv(allocationEnv, v)
+		cmd, _ := runWithConfigDir(t, configDir, "list", "--remotepath", "/")
+		if got := cmd.Flag("allocation").Value.String(); got != testDefaultAllocation {
+			t.Errorf("%s=%s: --allocation is %q, want %q", allocationEnv, v, got, testDefaultAllocation)
+		}
+		cmd.Flags().Set("allocation", "")
+		cmd.Flag("allocation").Changed = false
+	}
+
+	// --allocation wins over ZBOX_ALLOCATION
+	cmd, _ := runWithConfigDir(t, configDir, "list", "--allocation", "other", "--remotepath", "/")
+	if got := cmd.Flag("allocation").Value.String(); got != "other" {
+		t.Errorf("--allocation other is %q with %s set", got, allocationEnv)
+	}
+}
@@ -2,8 +2,8 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 	"os"
+	"strconv"
 
 	"github.com/0chain/gosdk/zboxcore/sdk"
 	"github.com/0chain/zboxcli/util"
@@ -31,7 +31,7 @@ var cpInfo = &cobra.Command{
 	Short: "Challenge pool information.",
 	Long:  `Challenge pool information.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags   = cmd.Flags()
@@ -39,25 +39,30 @@ var cpInfo = &cobra.Command{
 			err     error
 		)
 
-		doJSON, _ := cmd.Flags().GetBool("json")
-
 		if !flags.Changed("allocation") {
-			log.Fatal("missing required 'allocation' flag")
+			return usageError("missing required 'allocation' flag")
 		}
 
 		if allocID, err = flags.GetString("allocation"); err != nil {
-			log.Fatalf("can't get 'allocation' flag: %v", err)
+			return usageError("can't get 'allocation' flag:", err)
 		}
 
 		var info *sdk.ChallengePoolInfo
 		if info, err = sdk.GetChallengePoolInfo(allocID); err != nil {
-			log.Fatalf("Failed to get challenge pool info: %v", err)
+			return commandError("Failed to get challenge pool info:", err)
 		}
-		if doJSON {
-			util.PrintJSON(info)
-		} else {
+		header := []string{"id", "balance", "start_time", "expiration", "finalized"}
+		data := [][]string{{
+			info.ID,
+			strconv.FormatInt(int64(info.Balance), 10),
+			strconv.FormatInt(int64(info.StartTime), 10),
+			strconv.FormatInt(int64(info.Expiration), 10),
+			strconv.FormatBool(info.Finalized),
+		}}
+		printOutput(cmd, info, header, data, func() {
 			printChallengePoolInfo(info)
-		}
+		})
+		return nil
 	},
 }
 
@@ -66,5 +71,6 @@ func init() {
 
 	cpInfo.PersistentFlags().String("allocation", "",
 		"allocation identifier, required")
-	cpInfo.Flags().Bool("json", false, "pass this option to print response as json data")
+	cpInfo.MarkFlagRequired("allocation")
+	cpInfo.Flags().Bool("json", false, "pass this option to print response as json data, same as --output json")
 }
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/0chain/gosdk/zboxcore/sdk"
 	"github.com/spf13/cobra"
@@ -13,38 +12,33 @@ var addCollabCmd = &cobra.Command{
 	Short: "add collaborator for a file",
 	Long:  `add collaborator for a file`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		allocationID := cmd.Flag("allocation").Value.String()
 		if len(allocationID) == 0 {
-			PrintError("Error: allocation flag is missing")
-			os.Exit(1)
+			return usageError("Error: allocation flag is missing")
 		}
 
 		remotepath := cmd.Flag("remotepath").Value.String()
 		if len(remotepath) == 0 {
-			PrintError("Error: remotepath flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath flag is missing")
 		}
 
 		collabID := cmd.Flag("collabid").Value.String()
 		if len(collabID) == 0 {
-			PrintError("Error: collabid flag is missing")
-			os.Exit(1)
+			return usageError("Error: collabid flag is missing")
 		}
 
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation", err)
 		}
 
 		err = allocationObj.AddCollaborator(remotepath, collabID)
 		if err != nil {
-			PrintError(err.Error())
-			os.Exit(1)
+			return commandError(err)
 		}
 		fmt.Printf("Collaborator %s added successfully for the file %s \n", collabID, remotepath)
-		return
+		return nil
 	},
 }
 
@@ -53,38 +47,33 @@ var deleteCollabCmd = &cobra.Command{
 	Short: "delete collaborator for a file",
 	Long:  `delete collaborator for a file`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		allocationID := cmd.Flag("allocation").Value.String()
 		if len(allocationID) == 0 {
-			PrintError("Error: allocation flag is missing")
-			os.Exit(1)
+			return usageError("Error: allocation flag is missing")
 		}
 
 		remotepath := cmd.Flag("remotepath").Value.String()
 		if len(remotepath) == 0 {
-			PrintError("Error: remotepath flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath flag is missing")
 		}
 
 		collabID := cmd.Flag("collabid").Value.String()
 		if len(collabID) == 0 {
-			PrintError("Error: collabid flag is missing")
-			os.Exit(1)
+			return usageError("Error: collabid flag is missing")
 		}
 
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation", err)
 		}
 
 		err = allocationObj.RemoveCollaborator(remotepath, collabID)
 		if err != nil {
-			PrintError(err.Error())
-			os.Exit(1)
+			return commandError(err)
 		}
 		fmt.Printf("Collaborator %s removed successfully for the file %s \n", collabID, remotepath)
-		return
+		return nil
 	},
 }
 
@@ -2,8 +2,6 @@ package cmd
 
 import (
 	"encoding/json"
-	"fmt"
-	"os"
 	"sync"
 
 	"github.com/0chain/gosdk/zboxcore/sdk"
@@ -16,26 +14,22 @@ var commitCmd = &cobra.Command{
 	Short: "commit a file changes to chain ",
 	Long:  `commit a file changes to chain`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags()                      // fflags is a *flag.FlagSet
 		if fflags.Changed("allocation") == false { // check if the flag "path" is set
-			fmt.Println("Error: allocation flag is missing") // If not, we'll let the user know
-			return                                           // and return
+			return usageError("Error: allocation flag is missing")
 		}
 		if fflags.Changed("remotepath") == false {
-			fmt.Println("Error: remotepath flag is missing")
-			return
+			return usageError("Error: remotepath flag is missing")
 		}
 		if fflags.Changed("operation") == false {
-			fmt.Println("Error: operation flag is missing")
-			return
+			return usageError("Error: operation flag is missing")
 		}
 
 		allocationID := cmd.Flag("allocation").Value.String()
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			fmt.Println("Error fetching the allocation", err)
-			return
+			return commandError("Error fetching the allocation", err)
 		}
 
 		remotepath := cmd.Flag("remotepath").Value.String()
@@ -48,8 +42,7 @@ var commitCmd = &cobra.Command{
 
 		statsMap, err := allocationObj.GetFileStats(remotepath)
 		if err != nil {
-			PrintError("Error in getting information about the object." + err.Error())
-			os.Exit(1)
+			return commandError("Error in getting information about the object.", err)
 		}
 
 		isFile := false
@@ -64,8 +57,7 @@ var commitCmd = &cobra.Command{
 		if len(filemeta) > 0 {
 			err := json.Unmarshal([]byte(filemeta), fileMetaData)
 			if err != nil {
-				PrintError("failed to convert fileMeta." + err.Error())
-				os.Exit(1)
+				return commandError("failed to convert fileMeta.", err)
 			}
 		}
 
@@ -73,13 +65,17 @@ var commitCmd = &cobra.Command{
 			wg := &sync.WaitGroup{}
 			statusBar := &StatusBar{wg: wg}
 			wg.Add(1)
-			commitMetaTxn(remotepath, operation, authticket, lookuphash, allocationObj, fileMetaData, statusBar)
+			if err := commitMetaTxn(remotepath, operation, authticket, lookuphash, allocationObj, fileMetaData, statusBar); err != nil {
+				return err
+			}
 			wg.Wait()
 		} else {
-			commitFolderTxn(operation, remotepath, newvalue, allocationObj)
+			if err := commitFolderTxn(operation, remotepath, newvalue, allocationObj); err != nil {
+				return err
+			}
 		}
 
-		return
+		return nil
 	},
 }
 
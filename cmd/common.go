@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/0chain/gosdk/zboxcore/sdk"
+	"golang.org/x/term"
 	"gopkg.in/cheggaaa/pb.v1"
 )
 
@@ -16,7 +21,9 @@ const (
 )
 
 func (s *StatusBar) Started(allocationId, filePath string, op int, totalBytes int) {
-	s.b = pb.StartNew(totalBytes)
+	s.b = pb.New(totalBytes)
+	s.b.Output = s.out
+	s.b.Start()
 	s.b.Set(0)
 }
 func (s *StatusBar) InProgress(allocationId, filePath string, op int, completedBytes int, data []byte) {
@@ -31,7 +38,7 @@ func (s *StatusBar) Completed(allocationId, filePath string, filename string, mi
 	if !allocUnderRepair {
 		defer s.wg.Done()
 	}
-	fmt.Println("Status completed callback. Type = " + mimetype + ". Name = " + filename)
+	fmt.Fprintln(s.output(), "Status completed callback. Type = "+mimetype+". Name = "+filename)
 }
 
 func (s *StatusBar) Error(allocationID string, filePath string, op int, err error) {
@@ -39,6 +46,7 @@ func (s *StatusBar) Error(allocationID string, filePath string, op int, err erro
 		s.b.Finish()
 	}
 	s.success = false
+	s.err = err
 	if !allocUnderRepair {
 		defer s.wg.Done()
 	}
@@ -55,23 +63,33 @@ func (s *StatusBar) CommitMetaCompleted(request, response string, err error) {
 	defer s.wg.Done()
 	if err != nil {
 		s.success = false
+		s.err = err
 		PrintError("Error in commitMetaTransaction." + err.Error())
 	} else {
 		s.success = true
-		fmt.Println("Commit Metadata successful, Response :", response)
+		fmt.Fprintln(s.output(), "Commit Metadata successful, Response :", response)
 	}
 }
 
 func (s *StatusBar) RepairCompleted(filesRepaired int) {
 	defer s.wg.Done()
 	allocUnderRepair = false
-	fmt.Println("Repair file completed, Total files repaired: ", filesRepaired)
+	fmt.Fprintln(s.output(), "Repair file completed, Total files repaired: ", filesRepaired)
 }
 
 type StatusBar struct {
 	b       *pb.ProgressBar
 	wg      *sync.WaitGroup
+	out     io.Writer // progress and messages are printed to out, stdout if it is nil
 	success bool
+	err     error
+}
+
+func (s *StatusBar) output() io.Writer {
+	if s.out == nil {
+		return os.Stdout
+	}
+	return s.out
 }
 
 type ZCNStatus struct {
@@ -99,25 +117,41 @@ func PrintError(v ...interface{}) {
 	fmt.Fprintln(os.Stderr, v...)
 }
 
+// promptConfirm asks question on stderr and reads the answer from the terminal, it is confirmed by answer
+func promptConfirm(question, answer string) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, errors.New("can not ask for confirmation, stdin is not a terminal")
+	}
+	fmt.Fprint(os.Stderr, question)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	return strings.TrimSpace(line) == answer, nil
+}
+
 func PrintInfo(v ...interface{}) {
 	fmt.Fprintln(os.Stdin, v...)
 }
 
-func commitMetaTxn(path, crudOp, authTicket, lookupHash string, a *sdk.Allocation, fileMeta *sdk.ConsolidatedFileMeta, status *StatusBar) {
+// commitMetaTxn commits the meta of a file operation to the blockchain, status is done once it is committed.
+// status is also done if the commit fails to start, so callers can wait on it either way.
+func commitMetaTxn(path, crudOp, authTicket, lookupHash string, a *sdk.Allocation, fileMeta *sdk.ConsolidatedFileMeta, status *StatusBar) error {
 	err := a.CommitMetaTransaction(path, crudOp, authTicket, lookupHash, fileMeta, status)
 	if err != nil {
-		PrintError("Commit failed.", err)
-		os.Exit(1)
+		status.wg.Done()
+		return commandError("Commit failed.", err)
 	}
+	return nil
 }
 
-func commitFolderTxn(operation, preValue, currValue string, a *sdk.Allocation) {
+func commitFolderTxn(operation, preValue, currValue string, a *sdk.Allocation) error {
 	resp, err := a.CommitFolderChange(operation, preValue, currValue)
 	if err != nil {
-		PrintError("Commit failed.", err)
-		os.Exit(1)
+		return commandError("Commit failed.", err)
 	}
 	fmt.Println("Commit Metadata successful, Response :", resp)
+	return nil
 }
 
 func init() {
@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0chain/gosdk/zboxcore/fileref"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/spf13/cobra"
+)
+
+// completionCacheFile is the file of the config directory caching the values completed from the network
+const completionCacheFile = "completion_cache.json"
+
+// completionCacheTTL is how long completed values are reused, so pressing tab again does not wait for the network
+const completionCacheTTL = time.Minute
+
+// completionTimeout bounds the time the network is waited for, a completion gives nothing rather than hang the shell
+const completionTimeout = 5 * time.Second
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish]",
+	Short: "Generate a shell completion script",
+	Long: `Generate a completion script of zbox for bash, zsh or fish.
+Besides commands and flags, it completes --allocation with the allocations of the wallet and the allocation aliases,
+--remotepath with the files of the allocation, --blobber_id with the blobbers and --pool_id with the pools of the wallet.
+Values read from the network are cached for a minute.
+
+bash:
+  source <(zbox completion bash)
+  or, for every session: zbox completion bash > /etc/bash_completion.d/zbox
+
+zsh:
+  zbox completion zsh > "${fpath[1]}/_zbox"
+
+fish:
+  zbox completion fish > ~/.config/fish/completions/zbox.fish`,
+	Annotations:           map[string]string{annotationOffline: "true"},
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	Args:                  cobra.ExactValidArgs(1),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		switch args[0] {
+		case "bash":
+			err = rootCmd.GenBashCompletion(os.Stdout)
+		case "zsh":
+			err = rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			err = rootCmd.GenFishCompletion(os.Stdout, true)
+		}
+		if err != nil {
+			return commandError("Error generating the completion script:", err)
+		}
+		return nil
+	},
+}
+
+// completionCacheEntry is a cached list of completed values
+type completionCacheEntry struct {
+	Time   time.Time `json:"time"`
+	Values []string  `json:"values"`
+}
+
+// cachedCompletions returns the values cached under key, or else the values of fetch, which are cached.
+// The cache is in the config directory, as the values depend on the wallet and network of the profile.
+func cachedCompletions(key string, fetch func() ([]string, error)) ([]string, error) {
+	configDir, err := resolveConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	cachePath := filepath.Join(configDir, completionCacheFile)
+
+	cache := make(map[string]completionCacheEntry)
+	if buf, err := ioutil.ReadFile(cachePath); err == nil {
+		// a broken cache is replaced
+		json.Unmarshal(buf, &cache)
+	}
+	if entry, ok := cache[key]; ok && time.Since(entry.Time) < completionCacheTTL {
+		return entry.Values, nil
+	}
+
+	if err = initCompletionSDK(); err != nil {
+		return nil, err
+	}
+	values, err := fetchWithTimeout(fetch)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, entry := range cache {
+		if time.Since(entry.Time) >= completionCacheTTL {
+			delete(cache, k)
+		}
+	}
+	cache[key] = completionCacheEntry{Time: time.Now(), Values: values}
+	if buf, err := json.Marshal(cache); err == nil {
+		ioutil.WriteFile(cachePath, buf, 0600)
+	}
+	return values, nil
+}
+
+func fetchWithTimeout(fetch func() ([]string, error)) ([]string, error) {
+	type result struct {
+		values []string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		values, err := fetch()
+		done <- result{values, err}
+	}()
+	select {
+	case r := <-done:
+		return r.values, r.err
+	case <-time.After(completionTimeout):
+		return nil, fmt.Errorf("no response in %v", completionTimeout)
+	}
+}
+
+var completionSDKOnce sync.Once
+var completionSDKErr error
+
+// initCompletionSDK initializes the sdk the first time a completion needs the network.
+// The sdk prints to stdout, where the shell reads completions from, so completions are printed
+// to the original stdout and anything else is discarded.
+func initCompletionSDK() error {
+	completionSDKOnce.Do(func() {
+		rootCmd.SetOut(os.Stdout)
+		if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+			os.Stdout = devNull
+			log.SetOutput(devNull)
+		}
+		bSilent = true
+
+		// a prompt would hang the shell, so an encrypted wallet is only opened with a passphrase given
+		configDir, err := resolveConfigDir()
+		if err != nil {
+			completionSDKErr = err
+			return
+		}
+		if data, err := ioutil.ReadFile(getWalletFilePath(configDir)); err == nil && isEncryptedWallet(data) && !walletPassphraseSupplied() {
+			completionSDKErr = fmt.Errorf("the wallet is encrypted, set %s to complete from the network", walletPassphraseEnv)
+			return
+		}
+
+		_, completionSDKErr = fetchWithTimeout(func() ([]string, error) {
+			return nil, initConfig()
+		})
+	})
+	return completionSDKErr
+}
+
+// completionAllocation returns the allocation of the --allocation flag of cmd, or ZBOX_ALLOCATION or the default allocation
+func completionAllocation(cmd *cobra.Command) (string, error) {
+	if flag := cmd.Flag("allocation"); flag != nil && flag.Changed {
+		return resolveAllocation(flag.Value.String())
+	}
+	return envOrDefaultAllocation()
+}
+
+func completeAllocation(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	aliases, _ := loadAllocationAliases()
+	for name, id := range aliases {
+		completions = append(completions, name+"\talias of "+id)
+	}
+
+	allocations, err := cachedCompletions("allocations", func() ([]string, error) {
+		allocations, err := sdk.GetAllocations()
+		if err != nil {
+			return nil, err
+		}
+		values := make([]string, 0, len(allocations))
+		for _, a := range allocations {
+			values = append(values, fmt.Sprintf("%s\t%d bytes, expires %s", a.ID, a.Size, time.Unix(a.Expiration, 0).Format("2006-01-02")))
+		}
+		return values, nil
+	})
+	if err != nil {
+		cobra.CompErrorln("Error listing allocations: " + err.Error())
+	}
+	completions = append(completions, allocations...)
+	sort.Strings(completions)
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRemotePath completes the remote path being typed with the files of the directory it is in.
+// Directories end with / and no space is added, so their files can be completed next.
+func completeRemotePath(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	allocationID, err := completionAllocation(cmd)
+	if err != nil || len(allocationID) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if !strings.HasPrefix(toComplete, "/") {
+		toComplete = "/" + toComplete
+	}
+	dir := toComplete[:strings.LastIndex(toComplete, "/")+1]
+
+	values, err := cachedCompletions("remotepath:"+allocationID+":"+dir, func() ([]string, error) {
+		allocationObj, err := sdk.GetAllocation(allocationID)
+		if err != nil {
+			return nil, err
+		}
+		ref, err := allocationObj.ListDir(path.Clean(dir))
+		if err != nil {
+			return nil, err
+		}
+		values := make([]string, 0, len(ref.Children))
+		for _, child := range ref.Children {
+			if child.Type == fileref.DIRECTORY {
+				values = append(values, dir+child.Name+"/")
+			} else {
+				values = append(values, dir+child.Name)
+			}
+		}
+		return values, nil
+	})
+	if err != nil {
+		cobra.CompErrorln("Error listing " + dir + ": " + err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return values, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+func completeBlobberID(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	values, err := cachedCompletions("blobbers", func() ([]string, error) {
+		blobbers, err := sdk.GetBlobbers()
+		if err != nil {
+			return nil, err
+		}
+		values := make([]string, 0, len(blobbers))
+		for _, b := range blobbers {
+			values = append(values, string(b.ID)+"\t"+b.BaseURL)
+		}
+		return values, nil
+	})
+	if err != nil {
+		cobra.CompErrorln("Error listing blobbers: " + err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return values, cobra.ShellCompDirectiveNoFileComp
+}
+
+// allocationPoolCompletions returns the pools of read or write pool info, described by their allocation
+func allocationPoolCompletions(info *sdk.AllocationPoolStats) []string {
+	values := make([]string, 0, len(info.Pools))
+	for _, p := range info.Pools {
+		values = append(values, p.ID+"\tallocation "+string(p.AllocationID))
+	}
+	return values
+}
+
+func completeReadPoolID(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	values, err := cachedCompletions("pools:read", func() ([]string, error) {
+		info, err := sdk.GetReadPoolInfo("")
+		if err != nil {
+			return nil, err
+		}
+		return allocationPoolCompletions(info), nil
+	})
+	if err != nil {
+		cobra.CompErrorln("Error listing read pools: " + err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return values, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeWritePoolID(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	values, err := cachedCompletions("pools:write", func() ([]string, error) {
+		info, err := sdk.GetWritePoolInfo("")
+		if err != nil {
+			return nil, err
+		}
+		return allocationPoolCompletions(info), nil
+	})
+	if err != nil {
+		cobra.CompErrorln("Error listing write pools: " + err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return values, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeStakePoolID completes the delegate pools of the wallet, of the --blobber_id blobber if it is given
+func completeStakePoolID(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	blobberID := cmd.Flag("blobber_id").Value.String()
+	values, err := cachedCompletions("pools:stake:"+blobberID, func() ([]string, error) {
+		info, err := sdk.GetStakePoolUserInfo("")
+		if err != nil {
+			return nil, err
+		}
+		var values []string
+		for id, pools := range info.Pools {
+			if len(blobberID) > 0 && string(id) != blobberID {
+				continue
+			}
+			for _, p := range pools {
+				values = append(values, string(p.ID)+"\tblobber "+string(id))
+			}
+		}
+		return values, nil
+	})
+	if err != nil {
+		cobra.CompErrorln("Error listing stake pools: " + err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return values, cobra.ShellCompDirectiveNoFileComp
+}
+
+// flagCompletions are the completions of flags by name, for every command having the flag
+var flagCompletions = map[string]func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective){
+	"allocation": completeAllocation,
+	"remotepath": completeRemotePath,
+	"blobber_id": completeBlobberID,
+	"output": func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{outputTable, outputJSON, outputYAML, outputCSV}, cobra.ShellCompDirectiveNoFileComp
+	},
+	"error_format": func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{errorFormatText, errorFormatJSON}, cobra.ShellCompDirectiveNoFileComp
+	},
+	"log-level": func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"none", "fatal", "error", "info", "debug"}, cobra.ShellCompDirectiveNoFileComp
+	},
+	"log-format": func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{logFormatText, logFormatJSON}, cobra.ShellCompDirectiveNoFileComp
+	},
+	"profile": func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		names, _ := listProfiles()
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+// poolIDCompletions are the completions of the --pool_id flag, by command
+var poolIDCompletions = map[string]func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective){
+	"rp-unlock": completeReadPoolID,
+	"wp-unlock": completeWritePoolID,
+	"sp-unlock": completeStakePoolID,
+}
+
+// registerCompletions registers the completions of the flags of cmd and its subcommands.
+// It runs once all commands are added, as their flags are defined by the init of each file.
+func registerCompletions(cmd *cobra.Command) {
+	flags := cmd.LocalFlags()
+	for name, complete := range flagCompletions {
+		if flags.Lookup(name) != nil {
+			cmd.RegisterFlagCompletionFunc(name, complete)
+		}
+	}
+	if complete, ok := poolIDCompletions[cmd.Name()]; ok && flags.Lookup("pool_id") != nil {
+		cmd.RegisterFlagCompletionFunc("pool_id", complete)
+	}
+	for _, c := range cmd.Commands() {
+		registerCompletions(c)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
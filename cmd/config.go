@@ -0,0 +1,640 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0chain/gosdk/core/conf"
+	"github.com/0chain/zboxcli/util"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// sources of configuration values, besides flags and files
+const (
+	sourceDefault     = "default"
+	sourceBlockWorker = "block_worker"
+)
+
+// configIntKeys are the integer keys of config.yaml, with their defaults and ranges
+var configIntKeys = []struct {
+	key      string
+	def      int
+	min, max int
+}{
+	{"min_submit", conf.DefaultMinSubmit, 1, 100},
+	{"min_confirmation", conf.DefaultMinConfirmation, 1, 100},
+	{"confirmation_chain_length", conf.DefaultConfirmationChainLength, 1, 0},
+	{"max_txn_query", conf.DefaultMaxTxnQuery, 1, 0},
+	{"query_sleep_time", conf.DefaultQuerySleepTime, 1, 0},
+}
+
+// configKeys are the keys config.yaml may have
+var configKeys = []string{
+	"block_worker", "signature_scheme", "chain_id", "preferred_blobbers",
+	"min_submit", "min_confirmation", "confirmation_chain_length", "max_txn_query", "query_sleep_time",
+	"log_file", "log_level", "log_format", "log_max_size", "log_max_backups",
+}
+
+// signatureSchemes are the signature schemes of wallets
+var signatureSchemes = []string{"bls0chain", "ed25519"}
+
+// configEntry is a value of the effective configuration, and where it comes from
+type configEntry struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+// readYAMLFile returns the top level keys of a yaml file
+func readYAMLFile(path string) (map[string]interface{}, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	if err = yaml.Unmarshal(buf, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// flagSource returns the source of a root flag if it is set, empty otherwise
+func flagSource(name string) string {
+	if rootCmd.PersistentFlags().Changed(name) {
+		return "flag --" + name
+	}
+	return ""
+}
+
+// getNetworkFilePath returns the --network file in configDir, network.yaml by default
+func getNetworkFilePath(configDir string) string {
+	if networkFile == "" {
+		return filepath.Join(configDir, "network.yaml")
+	}
+	return filepath.Join(configDir, networkFile)
+}
+
+// effectiveConfig returns the configuration of the active profile, merged as initConfig merges it
+func effectiveConfig() ([]configEntry, error) {
+	var entries []configEntry
+	add := func(key string, value interface{}, sources ...string) {
+		source := sourceDefault
+		for _, s := range sources {
+			if len(s) > 0 {
+				source = s
+				break
+			}
+		}
+		entries = append(entries, configEntry{Key: key, Value: value, Source: source})
+	}
+
+	configDir, err := resolveConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	if len(cDir) > 0 {
+		add("config_dir", configDir, flagSource("configDir"), envSource("configDir"))
+	} else {
+		name, _ := activeProfile()
+		profileSource := flagSource("profile")
+		if len(profileSource) == 0 {
+			profileSource = envSource("profile")
+		}
+		if len(profileSource) == 0 && name != defaultProfile {
+			profileSource = "current profile"
+		}
+		add("profile", name, profileSource)
+		add("config_dir", configDir, "profile "+name)
+	}
+
+	configFile := getConfigFilePath(configDir)
+	add("config_file", configFile, flagSource("config"), envSource("config"))
+	cfg, raw, err := loadConfig(configDir)
+	if err != nil {
+		return nil, err
+	}
+	// a key of config.yaml is set by its environment variable, or else by the file
+	fileSource := func(key string) string {
+		if source := envSource(key); len(source) > 0 {
+			return source
+		}
+		if _, ok := raw[key]; ok {
+			return configFile
+		}
+		return ""
+	}
+	add("block_worker", cfg.BlockWorker, fileSource("block_worker"))
+	add("signature_scheme", cfg.SignatureScheme, fileSource("signature_scheme"))
+	add("chain_id", cfg.ChainID, fileSource("chain_id"))
+	blobbers := cfg.PreferredBlobbers
+	if blobbers == nil {
+		blobbers = []string{}
+	}
+	add("preferred_blobbers", blobbers, fileSource("preferred_blobbers"))
+	add("min_submit", cfg.MinSubmit, fileSource("min_submit"))
+	add("min_confirmation", cfg.MinConfirmation, fileSource("min_confirmation"))
+	add("confirmation_chain_length", cfg.ConfirmationChainLength, fileSource("confirmation_chain_length"))
+	add("max_txn_query", cfg.MaxTxnQuery, fileSource("max_txn_query"))
+	add("query_sleep_time", cfg.QuerySleepTime, fileSource("query_sleep_time"))
+
+	logs, err := loadLogSettings(configFile)
+	if err != nil {
+		return nil, err
+	}
+	logPath := logs.File
+	if !filepath.IsAbs(logPath) {
+		logPath = filepath.Join(configDir, logPath)
+	}
+	add("log_file", logPath, flagSource("log-file"), envSource("log-file"), fileSource("log_file"))
+	add("log_level", logs.Level, flagSource("log-level"), envSource("log-level"), fileSource("log_level"))
+	add("log_format", logs.Format, flagSource("log-format"), envSource("log-format"), fileSource("log_format"))
+	add("log_max_size", logs.MaxSize, fileSource("log_max_size"))
+	add("log_max_backups", logs.MaxBackups, fileSource("log_max_backups"))
+
+	networkPath := getNetworkFilePath(configDir)
+	add("network_file", networkPath, flagSource("network"), envSource("network"))
+	network, err := conf.LoadNetworkFile(networkPath)
+	if err == nil && network.IsValid() {
+		add("miners", network.Miners, networkPath)
+		add("sharders", network.Sharders, networkPath)
+	} else {
+		add("miners", cfg.BlockWorker+"/network", sourceBlockWorker)
+		add("sharders", cfg.BlockWorker+"/network", sourceBlockWorker)
+	}
+
+	// in the order of loadWallet
+	switch {
+	case len(os.Getenv(walletJSONEnv)) > 0 && !rootCmd.PersistentFlags().Changed("wallet_client_id"):
+		add("wallet", "wallet json", "env "+walletJSONEnv)
+	case len(walletClientID) > 0 && len(walletClientKey) > 0:
+		add("wallet", walletClientID, flagSource("wallet_client_id"), envSource("wallet_client_id"))
+	default:
+		add("wallet_file", getWalletFilePath(configDir), flagSource("wallet"), envSource("wallet"))
+	}
+	if allocationID := os.Getenv(allocationEnv); len(allocationID) > 0 {
+		add("allocation", allocationID, "env "+allocationEnv)
+	} else if allocationID, _ := defaultAllocation(); len(allocationID) > 0 {
+		add("allocation", allocationID, filepath.Join(configDir, defaultAllocationFile))
+	}
+	return entries, nil
+}
+
+// formatConfigValue prints lists comma separated in tables and csv
+func formatConfigValue(v interface{}) string {
+	if list, ok := v.([]string); ok {
+		return strings.Join(list, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and validate the configuration",
+	Long: `Show the effective configuration and where each value comes from, or validate config.yaml and network.yaml.
+Both use the config directory of the active profile, or --configDir.`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the effective configuration",
+	Long: `Show the configuration commands run with: the values of config.yaml, network.yaml, the root flags and the defaults,
+merged as commands merge them. The source of each value is a flag, a file, the active profile, the block worker or default.`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := effectiveConfig()
+		if err != nil {
+			return configError("Error reading the configuration, see zbox config validate:", err)
+		}
+		data := make([][]string, 0, len(entries))
+		for _, e := range entries {
+			data = append(data, []string{e.Key, formatConfigValue(e.Value), e.Source})
+		}
+		printOutput(cmd, entries, []string{"key", "value", "source"}, data, func() {
+			util.WriteTable(os.Stdout, []string{"KEY", "VALUE", "SOURCE"}, []string{}, data)
+		})
+		return nil
+	},
+}
+
+// levels of configDiagnostic
+const (
+	diagnosticOK      = "ok"
+	diagnosticWarning = "warning"
+	diagnosticError   = "error"
+)
+
+// configDiagnostic is a finding of config validate
+type configDiagnostic struct {
+	Level   string    `json:"level"`
+	Subject string    `json:"subject"`
+	Message string    `json:"message"`
+	kind    errorKind // of an error, the exit code of validate
+}
+
+// configValidator collects the diagnostics of config validate
+type configValidator struct {
+	mu          sync.Mutex
+	diagnostics []configDiagnostic
+}
+
+func (v *configValidator) add(level, subject, message string, kind errorKind) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.diagnostics = append(v.diagnostics, configDiagnostic{Level: level, Subject: subject, Message: message, kind: kind})
+}
+
+func (v *configValidator) ok(subject, message string) {
+	v.add(diagnosticOK, subject, message, "")
+}
+
+func (v *configValidator) warn(subject, message string) {
+	v.add(diagnosticWarning, subject, message, "")
+}
+
+func (v *configValidator) fail(subject, message string) {
+	v.add(diagnosticError, subject, message, kindConfig)
+}
+
+func (v *configValidator) unreachable(subject, message string) {
+	v.add(diagnosticError, subject, message, kindNetwork)
+}
+
+func (v *configValidator) count(level string) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	n := 0
+	for _, d := range v.diagnostics {
+		if d.Level == level {
+			n++
+		}
+	}
+	return n
+}
+
+// closestKey returns the known key a misspelt key is likely meant to be, empty if none is close
+func closestKey(key string, known []string) string {
+	best, bestDist := "", 3
+	for _, k := range known {
+		if d := editDistance(key, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// checkUnknownKeys warns of keys no command reads, which are often misspelt
+func (v *configValidator) checkUnknownKeys(file string, raw map[string]interface{}, known []string) {
+	for key := range raw {
+		if contains(known, key) {
+			continue
+		}
+		msg := "unknown key " + key + ", it is ignored"
+		if k := closestKey(key, known); len(k) > 0 {
+			msg += ", did you mean " + k + "?"
+		}
+		v.warn(file, msg)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// urlList returns the urls of a list key, reporting values which are not a list of urls
+func (v *configValidator) urlList(file, key string, value interface{}) []string {
+	list, ok := value.([]interface{})
+	if !ok {
+		v.fail(file, key+" must be a list of urls")
+		return nil
+	}
+	var urls []string
+	for _, item := range list {
+		s, ok := item.(string)
+		if !ok || !isURL(s) {
+			v.fail(file, fmt.Sprintf("%s has %v, which is not an http or https url", key, item))
+			continue
+		}
+		urls = append(urls, strings.TrimRight(s, "/"))
+	}
+	return urls
+}
+
+// checkConfigFile checks the keys and values of config.yaml, and returns its block worker and chain id
+func (v *configValidator) checkConfigFile(file string) (blockWorker, chainID string) {
+	before := v.count(diagnosticError)
+	raw, err := readYAMLFile(file)
+	if os.IsNotExist(err) {
+		if _, ok := lookupEnv("block_worker"); ok {
+			v.ok(file, "no config file, the config is set by "+envPrefix+" environment variables")
+			return "", ""
+		}
+		v.fail(file, "the config file does not exist, create it as described in the README, or use zbox profile create")
+		return "", ""
+	}
+	if err != nil {
+		v.fail(file, "invalid yaml: "+err.Error())
+		return "", ""
+	}
+	v.checkUnknownKeys(file, raw, configKeys)
+
+	_, blockWorkerEnv := lookupEnv("block_worker")
+	_, signatureSchemeEnv := lookupEnv("signature_scheme")
+	switch bw := raw["block_worker"].(type) {
+	case nil:
+		if blockWorkerEnv {
+			break
+		}
+		v.fail(file, "block_worker is missing, set it to the url of the network api, like https://beta.0chain.net/dns")
+	case string:
+		if !isURL(bw) {
+			v.fail(file, "block_worker "+bw+" is not an http or https url")
+		} else {
+			blockWorker = strings.TrimRight(bw, "/")
+		}
+	default:
+		v.fail(file, "block_worker must be a url")
+	}
+
+	switch s := raw["signature_scheme"].(type) {
+	case nil:
+		if signatureSchemeEnv {
+			break
+		}
+		v.fail(file, "signature_scheme is missing, set it to "+strings.Join(signatureSchemes, " or ")+" as the wallet")
+	case string:
+		if !contains(signatureSchemes, s) {
+			v.fail(file, "signature_scheme "+s+" is not "+strings.Join(signatureSchemes, " or "))
+		}
+	default:
+		v.fail(file, "signature_scheme must be "+strings.Join(signatureSchemes, " or "))
+	}
+
+	switch id := raw["chain_id"].(type) {
+	case nil:
+		v.warn(file, "chain_id is not set, the chain id of the network is not checked")
+	case string:
+		chainID = id
+	default:
+		v.fail(file, "chain_id must be a string")
+	}
+
+	if blobbers, ok := raw["preferred_blobbers"]; ok && blobbers != nil {
+		v.urlList(file, "preferred_blobbers", blobbers)
+	}
+
+	for _, k := range configIntKeys {
+		value, ok := raw[k.key]
+		if !ok {
+			continue
+		}
+		n, ok := value.(int)
+		switch {
+		case !ok:
+			v.fail(file, fmt.Sprintf("%s must be an integer", k.key))
+		case n < k.min:
+			v.warn(file, fmt.Sprintf("%s %d is less than %d, the default %d is used", k.key, n, k.min, k.def))
+		case k.max > 0 && n > k.max:
+			v.warn(file, fmt.Sprintf("%s %d is more than %d, %d is used", k.key, n, k.max, k.max))
+		}
+	}
+
+	var logs logSettings
+	buf, _ := yaml.Marshal(raw)
+	if err := yaml.Unmarshal(buf, &logs); err != nil {
+		v.fail(file, "invalid log settings: "+err.Error())
+	} else if err := logs.validate(); err != nil {
+		v.fail(file, err.Error())
+	}
+
+	if v.count(diagnosticError) == before {
+		v.ok(file, "valid")
+	}
+	return blockWorker, chainID
+}
+
+// checkConfigEnv checks the ZBOX_ environment variables of config.yaml keys, which override the file,
+// and returns the block worker and chain id they set, or else those of the file
+func (v *configValidator) checkConfigEnv(blockWorker, chainID string) (string, string) {
+	for _, key := range configKeys {
+		value, ok := lookupEnv(key)
+		if !ok {
+			continue
+		}
+		name := envName(key)
+		switch key {
+		case "block_worker":
+			if !isURL(value) {
+				v.fail(name, value+" is not an http or https url")
+				continue
+			}
+			blockWorker = strings.TrimRight(value, "/")
+		case "chain_id":
+			chainID = value
+		case "signature_scheme":
+			if !contains(signatureSchemes, value) {
+				v.fail(name, value+" is not "+strings.Join(signatureSchemes, " or "))
+				continue
+			}
+		}
+		v.ok(name, "overrides "+key+" of the config file")
+	}
+	return blockWorker, chainID
+}
+
+// checkNetworkFile checks network.yaml if it exists, and returns its miners and sharders
+func (v *configValidator) checkNetworkFile(file string) (miners, sharders []string) {
+	raw, err := readYAMLFile(file)
+	if os.IsNotExist(err) {
+		v.ok(file, "no network file, the miners and sharders of block_worker are used")
+		return nil, nil
+	}
+	if err != nil {
+		v.fail(file, "invalid yaml: "+err.Error())
+		return nil, nil
+	}
+	v.checkUnknownKeys(file, raw, []string{"miners", "sharders"})
+	if value, ok := raw["miners"]; ok {
+		miners = v.urlList(file, "miners", value)
+	}
+	if value, ok := raw["sharders"]; ok {
+		sharders = v.urlList(file, "sharders", value)
+	}
+	if len(miners) == 0 || len(sharders) == 0 {
+		v.warn(file, "the network file is ignored, as it needs both miners and sharders")
+		return nil, nil
+	}
+	v.ok(file, fmt.Sprintf("valid, %d miners and %d sharders", len(miners), len(sharders)))
+	return miners, sharders
+}
+
+// checkNetwork checks the block worker, miners and sharders respond, and the sharders are on chainID
+func (v *configValidator) checkNetwork(ctx context.Context, timeout time.Duration, blockWorker, chainID string, miners, sharders []string) {
+	client := &http.Client{Timeout: timeout}
+	if len(blockWorker) > 0 {
+		network, latency, err := probeBlockWorker(ctx, client, blockWorker)
+		switch {
+		case err != nil:
+			v.unreachable(blockWorker, "block_worker does not respond: "+err.Error())
+		case !network.IsValid():
+			v.fail(blockWorker, "block_worker responds without miners and sharders, is it the url of the network api?")
+		default:
+			v.ok(blockWorker, fmt.Sprintf("responds in %v, %d miners and %d sharders", latency.Round(time.Millisecond), len(network.Miners), len(network.Sharders)))
+			if len(miners) == 0 {
+				miners, sharders = network.Miners, network.Sharders
+			}
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	for _, miner := range miners {
+		wg.Add(1)
+		go func(miner string) {
+			defer wg.Done()
+			_, latency, err := probeWhoami(ctx, client, miner)
+			if err != nil {
+				v.unreachable(miner, "miner does not respond: "+err.Error())
+				return
+			}
+			v.ok(miner, fmt.Sprintf("miner responds in %v", latency.Round(time.Millisecond)))
+		}(miner)
+	}
+	for _, sharder := range sharders {
+		wg.Add(1)
+		go func(sharder string) {
+			defer wg.Done()
+			block, latency, err := probeSharder(ctx, client, sharder)
+			switch {
+			case err != nil:
+				v.unreachable(sharder, "sharder does not respond: "+err.Error())
+			case len(chainID) > 0 && block.ChainID != chainID:
+				v.fail(sharder, "sharder is on chain "+block.ChainID+", not on chain_id "+chainID+" of the config. Is block_worker the network of the wallet?")
+			default:
+				v.ok(sharder, fmt.Sprintf("sharder responds in %v, round %d", latency.Round(time.Millisecond), block.Round))
+			}
+		}(sharder)
+	}
+	wg.Wait()
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate config.yaml and network.yaml",
+	Long: `Validate config.yaml and network.yaml of the active profile: their keys and values,
+that block_worker, the miners and the sharders respond within --timeout, and that the sharders are on the chain_id of the config.
+Exits with the config exit code if a file is invalid, or the network exit code if a node does not respond.`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		offline, _ := cmd.Flags().GetBool("offline")
+		if timeout <= 0 {
+			return usageError("Error: timeout must be positive")
+		}
+
+		configDir, err := resolveConfigDir()
+		if err != nil {
+			return configError("Error:", err)
+		}
+		v := &configValidator{}
+		blockWorker, chainID := v.checkConfigFile(getConfigFilePath(configDir))
+		blockWorker, chainID = v.checkConfigEnv(blockWorker, chainID)
+		miners, sharders := v.checkNetworkFile(getNetworkFilePath(configDir))
+		if !offline {
+			v.checkNetwork(cmd.Context(), timeout, blockWorker, chainID, miners, sharders)
+		}
+
+		// errors first, each level in a stable order
+		rank := map[string]int{diagnosticError: 0, diagnosticWarning: 1, diagnosticOK: 2}
+		sort.SliceStable(v.diagnostics, func(i, j int) bool {
+			a, b := v.diagnostics[i], v.diagnostics[j]
+			if rank[a.Level] != rank[b.Level] {
+				return rank[a.Level] < rank[b.Level]
+			}
+			return a.Subject < b.Subject
+		})
+
+		data := make([][]string, 0, len(v.diagnostics))
+		var errs int
+		kind := kindNetwork
+		for _, d := range v.diagnostics {
+			data = append(data, []string{d.Level, d.Subject, d.Message})
+			if d.Level == diagnosticError {
+				errs++
+				if d.kind == kindConfig {
+					kind = kindConfig
+				}
+			}
+		}
+		printOutput(cmd, v.diagnostics, []string{"level", "subject", "message"}, data, func() {
+			util.WriteTable(os.Stdout, []string{"LEVEL", "SUBJECT", "MESSAGE"}, []string{}, data)
+		})
+		if errs > 0 {
+			return newError(kind, fmt.Sprintf("Error: the configuration has %d error(s)", errs))
+		}
+		return interruptedError(cmd.Context())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configValidateCmd.Flags().Duration("timeout", 10*time.Second, "time each block worker, miner and sharder has to respond")
+	configValidateCmd.Flags().Bool("offline", false, "only validate the files, without connecting to the network")
+}
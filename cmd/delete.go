@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"sync"
 
 	"github.com/0chain/gosdk/zboxcore/sdk"
@@ -15,29 +14,25 @@ var deleteCmd = &cobra.Command{
 	Short: "delete file from blobbers",
 	Long:  `delete file from blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags()                      // fflags is a *flag.FlagSet
 		if fflags.Changed("allocation") == false { // check if the flag "path" is set
-			PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-			os.Exit(1)                                      // and return
+			return usageError("Error: allocation flag is missing")
 		}
 		if fflags.Changed("remotepath") == false {
-			PrintError("Error: remotepath flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath flag is missing")
 		}
 		commit, _ := cmd.Flags().GetBool("commit")
 		allocationID := cmd.Flag("allocation").Value.String()
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation", err)
 		}
 		remotepath := cmd.Flag("remotepath").Value.String()
 
 		statsMap, err := allocationObj.GetFileStats(remotepath)
 		if err != nil {
-			PrintError("Error in getting information about the object." + err.Error())
-			os.Exit(1)
+			return commandError("Error in getting information about the object.", err)
 		}
 
 		isFile := false
@@ -52,15 +47,13 @@ var deleteCmd = &cobra.Command{
 		if isFile && commit {
 			fileMeta, err = allocationObj.GetFileMeta(remotepath)
 			if err != nil {
-				PrintError("Failed to fetch metadata for the given file", err.Error())
-				os.Exit(1)
+				return commandError("Failed to fetch metadata for the given file", err)
 			}
 		}
 
 		err = allocationObj.DeleteFile(remotepath)
 		if err != nil {
-			PrintError("Delete failed.", err.Error())
-			os.Exit(1)
+			return commandError("Delete failed.", err)
 		}
 
 		fmt.Println(remotepath + " deleted")
@@ -71,13 +64,17 @@ var deleteCmd = &cobra.Command{
 				wg := &sync.WaitGroup{}
 				statusBar := &StatusBar{wg: wg}
 				wg.Add(1)
-				commitMetaTxn(remotepath, "Delete", "", "", allocationObj, fileMeta, statusBar)
+				if err := commitMetaTxn(remotepath, "Delete", "", "", allocationObj, fileMeta, statusBar); err != nil {
+					return err
+				}
 				wg.Wait()
 			} else {
-				commitFolderTxn("Delete", remotepath, "", allocationObj)
+				if err := commitFolderTxn("Delete", remotepath, "", allocationObj); err != nil {
+					return err
+				}
 			}
 		}
-		return
+		return nil
 	},
 }
 
@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
-	"strings"
+	"path"
+	"path/filepath"
 	"sync"
 
 	"github.com/0chain/gosdk/zboxcore/fileref"
@@ -17,11 +22,10 @@ var downloadCmd = &cobra.Command{
 	Short: "download file from blobbers",
 	Long:  `download file from blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags() // fflags is a *flag.FlagSet
 		if fflags.Changed("remotepath") == false && fflags.Changed("authticket") == false {
-			PrintError("Error: remotepath / authticket flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath / authticket flag is missing")
 		}
 
 		remotepath := cmd.Flag("remotepath").Value.String()
@@ -31,33 +35,45 @@ var downloadCmd = &cobra.Command{
 		commit, _ := cmd.Flags().GetBool("commit")
 		rxPay, _ := cmd.Flags().GetBool("rx_pay")
 		if len(remotepath) == 0 && len(authticket) == 0 {
-			PrintError("Error: remotepath / authticket flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath / authticket flag is missing")
 		}
 
 		localpath := cmd.Flag("localpath").Value.String()
 		allocationID := cmd.Flag("allocation").Value.String()
 
 		live, _ := cmd.Flags().GetBool("live")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		resume, _ := cmd.Flags().GetBool("resume")
+		verify, _ := cmd.Flags().GetBool("verify")
+		// the chunk size of files blobbers don't report it for
+		chunkSize, _ := cmd.Flags().GetInt("chunksize")
+
+		if localpath == stdioPath && (live || recursive || resume) {
+			return usageError("Error: --live, --recursive and --resume can not be used when downloading to stdout")
+		}
+		if resume && (live || recursive) {
+			return usageError("Error: --live and --recursive can not be used with --resume")
+		}
+		if !recursive && (cmd.Flags().Changed("include") || cmd.Flags().Changed("exclude")) {
+			return usageError("Error: --include and --exclude only work with --recursive")
+		}
 
 		if live {
 			delay, _ := cmd.Flags().GetInt("delay")
 
-			m3u8, err := createM3u8Downloader(localpath, remotepath, authticket, allocationID, lookuphash, rxPay, delay)
+			m3u8, err := createM3u8Downloader(cmd.Context(), localpath, remotepath, authticket, allocationID, lookuphash, rxPay, delay)
 
 			if err != nil {
-				PrintError("Error: download files and build playlist: ", err)
-				os.Exit(1)
+				return commandError("Error: download files and build playlist: ", err)
 			}
 
 			err = m3u8.Start()
 
 			if err != nil {
-				PrintError("Error: download files and build playlist: ", err)
-				os.Exit(1)
+				return transferError(cmd.Context(), "Error: download files and build playlist: ", err)
 			}
 
-			return
+			return nil
 
 		}
 
@@ -70,73 +86,109 @@ var downloadCmd = &cobra.Command{
 		endBlock, _ := cmd.Flags().GetInt64("endblock")
 
 		sdk.SetNumBlockDownloads(numBlocks)
-		wg := &sync.WaitGroup{}
-		statusBar := &StatusBar{wg: wg}
-		wg.Add(1)
-		var errE, err error
-		var allocationObj *sdk.Allocation
-
-		if len(authticket) > 0 {
-			at, err := sdk.InitAuthTicket(authticket).Unmarshall()
 
+		if recursive {
+			workers, _ := cmd.Flags().GetInt("workers")
+			pathFilter, err := newPathFilter(cmd, localpath)
 			if err != nil {
-				PrintError(err)
-				os.Exit(1)
+				return err
 			}
-
-			allocationObj, err = sdk.GetAllocationFromAuthTicket(authticket)
+			results, err := startRecursiveDownload(cmd.Context(), localpath, remotepath, authticket, allocationID, rxPay, commit, verify, int64(chunkSize), workers, pathFilter)
 			if err != nil {
-				PrintError("Error fetching the allocation", err)
-				os.Exit(1)
+				return commandError("Download failed.", err)
+			}
+			if failed := printTransferSummary(results); failed > 0 {
+				return transferFailedError(cmd.Context(), failed)
 			}
+			return nil
+		}
 
-			var filename string
+		file, err := resolveRemoteFile(allocationID, remotepath, authticket, lookuphash, rxPay)
+		if err != nil {
+			return commandError(err)
+		}
+		allocationObj := file.allocationObj
+		lookuphash = file.lookupHash
+
+		if verify && (thumbnail || startBlock != 0 || endBlock != 0) {
+			return usageError("Error: --verify can only be used to download whole files")
+		}
 
-			if at.RefType == fileref.FILE {
-				filename = at.FileName
-				lookuphash = at.FilePathHash
-			} else if len(lookuphash) > 0 {
-				fileMeta, err := allocationObj.GetFileMetaFromAuthTicket(authticket, lookuphash)
-				if err != nil {
-					PrintError("Either remotepath or lookuphash is required when using authticket of directory type")
-					os.Exit(1)
+		if localpath == stdioPath {
+			if thumbnail {
+				return usageError("Error: --thumbnail can not be used when downloading to stdout")
+			}
+			// stdout is kept for the file content, progress and messages are printed to stderr
+			out := os.Stdout
+			meta, err := file.getFileMeta()
+			if err == nil {
+				fileChunkSize := file.chunkSize(int64(chunkSize))
+				if verify {
+					hasher := newMerkleHasher(fileChunkSize, allocationObj.DataShards, len(meta.EncryptedKey) > 0)
+					err = streamDownload(cmd.Context(), io.MultiWriter(out, hasher), file, meta, fileChunkSize, startBlock, endBlock, numBlocks)
+					if err == nil {
+						var localHash string
+						if localHash, err = hasher.Hash(); err == nil {
+							err = checkIntegrity(meta.Path, localHash, meta.Hash)
+						}
+					}
+				} else {
+					err = streamDownload(cmd.Context(), out, file, meta, fileChunkSize, startBlock, endBlock, numBlocks)
 				}
-				filename = fileMeta.Name
-			} else if len(remotepath) > 0 {
-				lookuphash = fileref.GetReferenceLookup(allocationObj.Tx, remotepath)
+			}
+			if err != nil {
+				return transferError(cmd.Context(), "Download failed.", err)
+			}
+			if commit {
+				statusBar := &StatusBar{wg: &sync.WaitGroup{}, out: os.Stderr}
+				statusBar.wg.Add(1)
+				if err := commitMetaTxn(remotepath, "Download", authticket, lookuphash, allocationObj, nil, statusBar); err != nil {
+					return err
+				}
+				statusBar.wg.Wait()
+			}
+			return nil
+		}
 
-				pathnames := strings.Split(remotepath, "/")
-				filename = pathnames[len(pathnames)-1]
-			} else {
-				PrintError("Either remotepath or lookuphash is required when using authticket of directory type")
-				os.Exit(1)
+		if resume {
+			if thumbnail || startBlock != 0 || endBlock != 0 {
+				return usageError("Error: --thumbnail, --startblock and --endblock can not be used with --resume")
+			}
+			err = resumeDownload(cmd.Context(), localpath, file, file.chunkSize(int64(chunkSize)), numBlocks, verify)
+			if err != nil {
+				return transferError(cmd.Context(), "Download failed.", err)
 			}
+			if commit {
+				statusBar := &StatusBar{wg: &sync.WaitGroup{}}
+				statusBar.wg.Add(1)
+				if err := commitMetaTxn(remotepath, "Download", authticket, lookuphash, allocationObj, nil, statusBar); err != nil {
+					return err
+				}
+				statusBar.wg.Wait()
+			}
+			return nil
+		}
 
+		wg := &sync.WaitGroup{}
+		statusBar := &StatusBar{wg: wg}
+		wg.Add(1)
+		var errE error
+
+		if len(authticket) > 0 {
 			if thumbnail {
 				errE = allocationObj.DownloadThumbnailFromAuthTicket(localpath,
-					authticket, lookuphash, filename, rxPay, statusBar)
+					authticket, lookuphash, file.fileName, rxPay, statusBar)
 			} else {
 				if startBlock != 0 || endBlock != 0 {
 					errE = allocationObj.DownloadFromAuthTicketByBlocks(
 						localpath, authticket, startBlock, endBlock, numBlocks,
-						lookuphash, filename, rxPay, statusBar)
+						lookuphash, file.fileName, rxPay, statusBar)
 				} else {
 					errE = allocationObj.DownloadFromAuthTicket(localpath,
-						authticket, lookuphash, filename, rxPay, statusBar)
+						authticket, lookuphash, file.fileName, rxPay, statusBar)
 				}
 			}
-		} else if len(remotepath) > 0 {
-			if fflags.Changed("allocation") == false { // check if the flag "path" is set
-				PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-				os.Exit(1)                                      // and return
-			}
-			allocationID := cmd.Flag("allocation").Value.String()
-			allocationObj, err = sdk.GetAllocation(allocationID)
-
-			if err != nil {
-				PrintError("Error fetching the allocation", err)
-				os.Exit(1)
-			}
+		} else {
 			if thumbnail {
 				errE = allocationObj.DownloadThumbnail(localpath, remotepath, statusBar)
 			} else {
@@ -149,28 +201,283 @@ var downloadCmd = &cobra.Command{
 		}
 
 		if errE == nil {
+			stop := cancelDownloadOnInterrupt(cmd.Context(), allocationObj, file.downloadKey())
 			wg.Wait()
+			stop()
 		} else {
-			PrintError("Download failed.", errE.Error())
-			os.Exit(1)
+			return commandError("Download failed.", errE)
 		}
 		if !statusBar.success {
-			os.Exit(1)
+			return transferError(cmd.Context(), "Download failed.", statusBar.err)
+		}
+		if verify {
+			meta, err := file.getFileMeta()
+			if err == nil {
+				downloadedPath := localpath
+				if info, statErr := os.Stat(localpath); statErr == nil && info.IsDir() {
+					downloadedPath = filepath.Join(localpath, meta.Name)
+				}
+				err = verifyDownload(downloadedPath, meta.Path, meta.Hash, file.chunkSize(int64(chunkSize)), allocationObj.DataShards, len(meta.EncryptedKey) > 0)
+			}
+			if err != nil {
+				return commandError(err)
+			}
+			fmt.Println("Integrity verified:", meta.Path)
 		}
 		if commit {
 			statusBar.wg.Add(1)
-			commitMetaTxn(remotepath, "Download", authticket, lookuphash, allocationObj, nil, statusBar)
+			if err := commitMetaTxn(remotepath, "Download", authticket, lookuphash, allocationObj, nil, statusBar); err != nil {
+				return err
+			}
 			statusBar.wg.Wait()
 		}
-		return
+		return nil
 	},
 }
 
+// resolveRemoteFile finds the allocation of the file to download, and its lookup hash and name when authTicket is used
+func resolveRemoteFile(allocationID, remotePath, authTicket, lookupHash string, rxPay bool) (*remoteFile, error) {
+	file := &remoteFile{remotePath: remotePath, authTicket: authTicket, lookupHash: lookupHash, rxPay: rxPay}
+
+	if len(authTicket) == 0 {
+		if len(allocationID) == 0 {
+			return nil, errors.New("Error: allocation flag is missing")
+		}
+		allocationObj, err := sdk.GetAllocation(allocationID)
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching the allocation: %s", err)
+		}
+		file.allocationObj = allocationObj
+		return file, nil
+	}
+
+	at, err := sdk.InitAuthTicket(authTicket).Unmarshall()
+	if err != nil {
+		return nil, err
+	}
+
+	file.allocationObj, err = sdk.GetAllocationFromAuthTicket(authTicket)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching the allocation: %s", err)
+	}
+
+	if at.RefType == fileref.FILE {
+		file.fileName = at.FileName
+		file.lookupHash = at.FilePathHash
+	} else if len(lookupHash) > 0 {
+		fileMeta, err := file.allocationObj.GetFileMetaFromAuthTicket(authTicket, lookupHash)
+		if err != nil {
+			return nil, errors.New("Either remotepath or lookuphash is required when using authticket of directory type")
+		}
+		file.fileName = fileMeta.Name
+	} else if len(remotePath) > 0 {
+		file.lookupHash = fileref.GetReferenceLookup(file.allocationObj.Tx, remotePath)
+		file.fileName = path.Base(remotePath)
+	} else {
+		return nil, errors.New("Either remotepath or lookuphash is required when using authticket of directory type")
+	}
+	return file, nil
+}
+
+// startRecursiveDownload mirrors remote directory remotePath, or the directory shared by authTicket, into localPath.
+// Files and directories skipped by pathFilter are not downloaded, chunkSize is the chunk size of files blobbers don't report it for.
+func startRecursiveDownload(ctx context.Context, localPath, remotePath, authTicket, allocationID string, rxPay, commit, verify bool, chunkSize int64, workers int, pathFilter *pathFilter) ([]transferResult, error) {
+	var (
+		allocationObj *sdk.Allocation
+		root          *sdk.ListResult
+		listDir       func(dir *sdk.ListResult) (*sdk.ListResult, error)
+		err           error
+	)
+
+	if len(authTicket) > 0 {
+		allocationObj, err = sdk.GetAllocationFromAuthTicket(authTicket)
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching the allocation: %s", err)
+		}
+		at := sdk.InitAuthTicket(authTicket)
+		isDir, err := at.IsDir()
+		if err != nil {
+			return nil, err
+		}
+		if !isDir {
+			return nil, errors.New("invalid operation. Auth ticket is not for a directory")
+		}
+		lookupHash, err := at.GetLookupHash()
+		if err != nil {
+			return nil, fmt.Errorf("Error getting the lookuphash from authticket: %s", err)
+		}
+		listDir = func(dir *sdk.ListResult) (*sdk.ListResult, error) {
+			return allocationObj.ListDirFromAuthTicket(authTicket, dir.LookupHash)
+		}
+		root, err = listDir(&sdk.ListResult{LookupHash: lookupHash})
+	} else {
+		if len(allocationID) == 0 {
+			return nil, errors.New("Error: allocation flag is missing")
+		}
+		allocationObj, err = sdk.GetAllocation(allocationID)
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching the allocation: %s", err)
+		}
+		listDir = func(dir *sdk.ListResult) (*sdk.ListResult, error) {
+			return allocationObj.ListDir(dir.Path)
+		}
+		root, err = listDir(&sdk.ListResult{Path: remotePath})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(localPath, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	var (
+		skipped []transferResult
+		tasks   []transferTask
+	)
+	err = walkRemoteDir(root, "", listDir, func(relPath string, child *sdk.ListResult) error {
+		if pathFilter.Skip(relPath, child.Type == fileref.DIRECTORY) {
+			return filepath.SkipDir
+		}
+		lPath := filepath.Join(localPath, filepath.FromSlash(relPath))
+		if child.Type == fileref.DIRECTORY {
+			return os.MkdirAll(lPath, os.ModePerm)
+		}
+
+		rPath := child.Path
+		if len(authTicket) > 0 {
+			rPath = relPath
+		}
+		fileChunkSize := func() int64 {
+			if len(authTicket) > 0 {
+				return chunkSize
+			}
+			return remoteChunkSize(allocationObj, child.Path, chunkSize)
+		}
+		if isSameLocalFile(lPath, child, fileChunkSize, allocationObj.DataShards) {
+			skipped = append(skipped, transferResult{LocalPath: lPath, RemotePath: rPath, Skipped: true})
+			return nil
+		}
+		tasks = append(tasks, transferTask{
+			LocalPath:  lPath,
+			RemotePath: rPath,
+			Size:       child.ActualSize,
+			Run: func(status *transferStatus) error {
+				return downloadFile(ctx, allocationObj, lPath, child, authTicket, rxPay, commit, verify, fileChunkSize, status)
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := newTransferScheduler(ctx, workers).Run(tasks)
+	return append(skipped, results...), nil
+}
+
+// walkRemoteDir calls visit for every child of dir, descending into sub directories with listDir
+// unless visit returns filepath.SkipDir for them
+func walkRemoteDir(dir *sdk.ListResult, relDir string, listDir func(dir *sdk.ListResult) (*sdk.ListResult, error), visit func(relPath string, child *sdk.ListResult) error) error {
+	for _, child := range dir.Children {
+		relPath := path.Join(relDir, child.Name)
+		if err := visit(relPath, child); err == filepath.SkipDir {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if child.Type != fileref.DIRECTORY {
+			continue
+		}
+		sub, err := listDir(child)
+		if err != nil {
+			return err
+		}
+		if err := walkRemoteDir(sub, relPath, listDir, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSameLocalFile reports whether localPath already holds the same content as remote file,
+// chunkSize is only asked for the chunk size file was uploaded with when the sizes match
+func isSameLocalFile(localPath string, file *sdk.ListResult, chunkSize func() int64, dataShards int) bool {
+	info, err := os.Stat(localPath)
+	if err != nil || info.IsDir() || info.Size() != file.ActualSize {
+		return false
+	}
+	if len(file.Hash) == 0 {
+		return true
+	}
+	hash, err := computeFileHash(localPath, chunkSize(), dataShards, len(file.EncryptionKey) > 0)
+	return err == nil && hash == file.Hash
+}
+
+// downloadFile downloads a single remote file to localPath, replacing any stale local copy, and waits until it is done
+func downloadFile(ctx context.Context, allocationObj *sdk.Allocation, localPath string, file *sdk.ListResult, authTicket string, rxPay, commit, verify bool, chunkSize func() int64, status *transferStatus) error {
+	if err := os.Remove(localPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	status.wg.Add(1)
+	var err error
+	downloadKey := file.Path
+	if len(authTicket) > 0 {
+		downloadKey = file.LookupHash
+		err = allocationObj.DownloadFromAuthTicket(localPath, authTicket, file.LookupHash, file.Name, rxPay, status)
+	} else {
+		err = allocationObj.DownloadFile(localPath, file.Path, status)
+	}
+	if err != nil {
+		return err
+	}
+	stop := cancelDownloadOnInterrupt(ctx, allocationObj, downloadKey)
+	err = status.wait()
+	stop()
+	if err != nil {
+		return err
+	}
+
+	if verify {
+		if err = verifyDownload(localPath, file.Path, file.Hash, chunkSize(), allocationObj.DataShards, len(file.EncryptionKey) > 0); err != nil {
+			return err
+		}
+	}
+
+	if commit {
+		status.wg.Add(1)
+		err = allocationObj.CommitMetaTransaction(file.Path, "Download", authTicket, file.LookupHash, nil, status)
+		if err != nil {
+			return err
+		}
+		return status.wait()
+	}
+
+	return nil
+}
+
+// cancelDownloadOnInterrupt cancels the download of key, a remote path or a lookup hash for auth tickets,
+// once ctx is done. The returned stop func must be called when the download is over.
+func cancelDownloadOnInterrupt(ctx context.Context, allocationObj *sdk.Allocation, key string) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			allocationObj.CancelDownload(key)
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(downloadCmd)
 	downloadCmd.PersistentFlags().String("allocation", "", "Allocation ID")
 	downloadCmd.PersistentFlags().String("remotepath", "", "Remote path to download")
-	downloadCmd.PersistentFlags().String("localpath", "", "Local path of file to download")
+	downloadCmd.PersistentFlags().String("localpath", "", "Local path of file to download, - to write it to stdout")
 	downloadCmd.PersistentFlags().String("authticket", "", "Auth ticket fot the file to download if you dont own it")
 	downloadCmd.PersistentFlags().String("lookuphash", "", "The remote lookuphash of the object retrieved from the list")
 	downloadCmd.Flags().BoolP("thumbnail", "t", false, "pass this option to download only the thumbnail")
@@ -180,6 +487,13 @@ func init() {
 	downloadCmd.Flags().Int64P("endblock", "e", 0, "pass this option to download till specific block number")
 	downloadCmd.Flags().IntP("blockspermarker", "b", 10, "pass this option to download multiple blocks per marker")
 
+	downloadCmd.Flags().Bool("verify", false, "pass this option to compare the hash of the downloaded file with the hash blobbers hold for it")
+	downloadCmd.Flags().Int("chunksize", sdk.CHUNK_SIZE, "chunk size the files were uploaded with, for --verify and skipping up to date files when blobbers don't report it")
+	downloadCmd.Flags().Bool("resume", false, "pass this option to continue an interrupted download into the partial file at --localpath")
+	downloadCmd.Flags().Bool("recursive", false, "pass this option to download every file in --remotepath directory, or in the directory shared by --authticket, to --localpath directory")
+	downloadCmd.Flags().Int("workers", defaultTransferWorkers, "number of files downloaded in parallel. only works with --recursive.")
+	addFilterFlags(downloadCmd)
+
 	downloadCmd.Flags().Bool("live", false, "start m3u8 downloader,and automatically generate media playlist(m3u8) on --localpath")
 	downloadCmd.Flags().Int("delay", 5, "pass segment duration to generate media playlist(m3u8). only works with --live. default duration is 5s.")
 
@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// makeFifo makes a named pipe at path, it fails if path exists
+func makeFifo(path string) error {
+	return syscall.Mkfifo(path, 0600)
+}
+
+// releaseFifo opens the named pipe at path for writing and closes it,
+// so a reader waiting for a writer which will not come reads EOF
+func releaseFifo(path string) {
+	if f, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+		f.Close()
+	}
+}
@@ -0,0 +1,10 @@
+package cmd
+
+import "errors"
+
+// makeFifo fails on windows, which has no named pipes on the file system, so downloads to stdout go through scratch files
+func makeFifo(path string) error {
+	return errors.New("named pipes are not supported on windows")
+}
+
+func releaseFifo(path string) {}
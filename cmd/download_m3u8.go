@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -16,6 +17,7 @@ import (
 // M3u8Downloader download files from blobber's dir, and build them into a local m3u8 playlist
 type M3u8Downloader struct {
 	sync.RWMutex
+	ctx   context.Context
 	delay int
 
 	localDir     string
@@ -31,10 +33,11 @@ type M3u8Downloader struct {
 	items          []MediaItem
 	waitToDownload chan MediaItem
 	playlist       *sdk.MediaPlaylist
+	playlistFile   *playlistWriter
 	done           chan error
 }
 
-func createM3u8Downloader(localPath, remotePath, authTicket, allocationID, lookupHash string, rxPay bool, delay int) (*M3u8Downloader, error) {
+func createM3u8Downloader(ctx context.Context, localPath, remotePath, authTicket, allocationID, lookupHash string, rxPay bool, delay int) (*M3u8Downloader, error) {
 	if len(remotePath) == 0 && (len(authTicket) == 0) {
 		return nil, errors.New("Error: remotepath / authticket flag is missing")
 	}
@@ -50,8 +53,10 @@ func createM3u8Downloader(localPath, remotePath, authTicket, allocationID, looku
 	if err != nil {
 		return nil, err
 	}
+	writer := &playlistWriter{file: file}
 
 	downloader := &M3u8Downloader{
+		ctx:            ctx,
 		localDir:       dir,
 		localPath:      localPath,
 		remotePath:     remotePath,
@@ -59,7 +64,8 @@ func createM3u8Downloader(localPath, remotePath, authTicket, allocationID, looku
 		allocationID:   allocationID,
 		rxPay:          rxPay,
 		waitToDownload: make(chan MediaItem, 100),
-		playlist:       sdk.NewMediaPlaylist(delay, dir, file),
+		playlist:       sdk.NewMediaPlaylist(delay, dir, writer),
+		playlistFile:   writer,
 		done:           make(chan error, 1),
 	}
 
@@ -103,16 +109,105 @@ func createM3u8Downloader(localPath, remotePath, authTicket, allocationID, looku
 	return downloader, nil
 }
 
-// Start start to download ,and build playlist
+// Start start to download ,and build playlist. It stops once ctx is done, and closes the playlist.
+// The playlist is played by the sdk, from NewMediaPlaylist.
 func (d *M3u8Downloader) Start() error {
 
 	go d.autoDownload()
 	go d.autoRefreshList()
-	go d.playlist.Play()
 
-	err := <-d.done
+	select {
+	case err := <-d.done:
+		return err
+	case <-d.ctx.Done():
+		if err := d.closePlaylist(); err != nil {
+			return err
+		}
+		return d.ctx.Err()
+	}
+}
+
+// closePlaylist marks the playlist as ended. The sdk keeps playing it, its writes are dropped once it is closed.
+func (d *M3u8Downloader) closePlaylist() error {
+	return d.playlistFile.Close()
+}
+
+// playlistWriter is the file of the playlist the sdk writes to. Its writes are serialized with Close,
+// which can't stop the goroutine of the sdk playing the playlist, so they are dropped once it is closed.
+type playlistWriter struct {
+	sync.Mutex
+	file   *os.File
+	buf    []byte // playlist written since it was last truncated
+	synced []byte // playlist as of the last Sync, once the sdk has written all of it
+	closed bool
+}
+
+func (w *playlistWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	n, err := w.file.Write(p)
+	w.buf = append(w.buf, p[:n]...)
+	return n, err
+}
+
+func (w *playlistWriter) Seek(offset int64, whence int) (int64, error) {
+	w.Lock()
+	defer w.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	return w.file.Seek(offset, whence)
+}
+
+func (w *playlistWriter) Truncate(size int64) error {
+	w.Lock()
+	defer w.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	if size < int64(len(w.buf)) {
+		w.buf = w.buf[:size]
+	}
+	return w.file.Truncate(size)
+}
 
-	return err
+func (w *playlistWriter) Sync() error {
+	w.Lock()
+	defer w.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	w.synced = append(w.synced[:0], w.buf...)
+	return w.file.Sync()
+}
+
+// Close rewrites the playlist as of the last Sync, in case the sdk was writing it, ends it and closes the file
+func (w *playlistWriter) Close() error {
+	w.Lock()
+	defer w.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.file.Seek(0, 0); err != nil {
+		return err
+	}
+	if _, err := w.file.Write(w.synced); err != nil {
+		return err
+	}
+	if _, err := w.file.WriteString("#EXT-X-ENDLIST\n"); err != nil {
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	return w.file.Close()
 }
 
 func (d *M3u8Downloader) addToDownload(item MediaItem) {
@@ -121,7 +216,12 @@ func (d *M3u8Downloader) addToDownload(item MediaItem) {
 
 func (d *M3u8Downloader) autoDownload() {
 	for {
-		item := <-d.waitToDownload
+		var item MediaItem
+		select {
+		case item = <-d.waitToDownload:
+		case <-d.ctx.Done():
+			return
+		}
 		//fmt.Println("download: ", item.Name)
 		for i := 0; i < 3; i++ {
 			if path, err := d.download(item); err == nil {
@@ -133,7 +233,7 @@ func (d *M3u8Downloader) autoDownload() {
 }
 
 func (d *M3u8Downloader) autoRefreshList() {
-	for {
+	for d.ctx.Err() == nil {
 		list, err := d.getList()
 		if err != nil {
 			logger.Logger.Error("[m3u8]", err)
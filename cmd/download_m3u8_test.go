@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0chain/gosdk/zboxcore/sdk"
+)
+
+func TestClosedPlaylistIsNotRewritten(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.ts", "b.ts"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	localPath := filepath.Join(dir, "playlist.m3u8")
+	file, err := os.Create(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := &playlistWriter{file: file}
+	playlist := sdk.NewMediaPlaylist(5, dir, writer)
+
+	playlist.Append("a.ts")
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		buf, _ := ioutil.ReadFile(localPath)
+		if strings.Contains(string(buf), "a.ts") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the sdk did not play a.ts")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the sdk plays b.ts after the playlist is closed, once it is done sleeping after a.ts
+	playlist.Append("b.ts")
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+
+	buf, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(buf)
+	if !strings.HasPrefix(got, "#EXTM3U\n") || !strings.Contains(got, "a.ts\n") || !strings.HasSuffix(got, "#EXT-X-ENDLIST\n") {
+		t.Errorf("closed playlist is\n%s", got)
+	}
+	if strings.Contains(got, "b.ts") {
+		t.Errorf("playlist was written after it was closed:\n%s", got)
+	}
+}
@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/0chain/gosdk/zboxcore/fileref"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"gopkg.in/cheggaaa/pb.v1"
+)
+
+// remoteFile is a remote file to download, either by remotePath of an owned allocation or by an auth ticket
+type remoteFile struct {
+	allocationObj *sdk.Allocation
+	remotePath    string
+	authTicket    string
+	lookupHash    string
+	fileName      string
+	rxPay         bool
+}
+
+// downloadKey is the key the sdk tracks the download of f by, see cancelDownloadOnInterrupt
+func (f *remoteFile) downloadKey() string {
+	if len(f.authTicket) > 0 {
+		return f.lookupHash
+	}
+	return f.remotePath
+}
+
+func (f *remoteFile) getFileMeta() (*sdk.ConsolidatedFileMeta, error) {
+	if len(f.authTicket) > 0 {
+		return f.allocationObj.GetFileMetaFromAuthTicket(f.authTicket, f.lookupHash)
+	}
+	return f.allocationObj.GetFileMeta(f.remotePath)
+}
+
+// chunkSize returns the chunk size f was uploaded with, or fallback when blobbers can't be asked for it:
+// the sdk has no way to get the file ref of a file shared by auth ticket
+func (f *remoteFile) chunkSize(fallback int64) int64 {
+	if len(f.authTicket) > 0 {
+		return fallback
+	}
+	return remoteChunkSize(f.allocationObj, f.remotePath, fallback)
+}
+
+func (f *remoteFile) downloadBlocks(localPath string, startBlock, endBlock int64, numBlocks int, status sdk.StatusCallback) error {
+	if len(f.authTicket) > 0 {
+		return f.allocationObj.DownloadFromAuthTicketByBlocks(localPath, f.authTicket, startBlock, endBlock, numBlocks, f.lookupHash, f.fileName, f.rxPay, status)
+	}
+	return f.allocationObj.DownloadFileByBlock(localPath, f.remotePath, startBlock, endBlock, numBlocks, status)
+}
+
+// blockSizeOf is how many bytes of the original file a block holds, a block being a chunk on every data shard
+func blockSizeOf(meta *sdk.ConsolidatedFileMeta, chunkSize int64, dataShards int) int64 {
+	if len(meta.EncryptedKey) > 0 {
+		chunkSize -= 16 + 2*1024
+	}
+	return chunkSize * int64(dataShards)
+}
+
+// streamDownload downloads blocks startBlock to endBlock (0 for the last block) of f, uploaded in chunks of chunkSize,
+// and writes them to w in order.
+// Each range of numBlocks blocks is read from a named pipe while the sdk downloads it, see downloadRange.
+// Progress is rendered on stderr, as w may be stdout.
+func streamDownload(ctx context.Context, w io.Writer, f *remoteFile, meta *sdk.ConsolidatedFileMeta, chunkSize, startBlock, endBlock int64, numBlocks int) error {
+	if meta.Type != fileref.FILE {
+		return errors.New("invalid operation. remote path is not a file")
+	}
+
+	blockSize := blockSizeOf(meta, chunkSize, f.allocationObj.DataShards)
+	totalBlocks := (meta.Size + blockSize - 1) / blockSize
+	if startBlock < 1 {
+		startBlock = 1
+	}
+	if endBlock == 0 || endBlock > totalBlocks {
+		endBlock = totalBlocks
+	}
+	if numBlocks < 1 {
+		numBlocks = 1
+	}
+
+	// blocks are padded up to blockSize, the padding of the last block must not be written
+	remaining := meta.Size - (startBlock-1)*blockSize
+	if endBlock < totalBlocks {
+		remaining = (endBlock - startBlock + 1) * blockSize
+	}
+
+	bar := pb.New64(remaining).SetUnits(pb.U_BYTES)
+	bar.Output = os.Stderr
+	bar.Start()
+	defer bar.Finish()
+
+	// the sdk only downloads to a local path, the pipe is made there
+	pipeDir, err := ioutil.TempDir("", "zbox-download")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(pipeDir)
+	pipePath := filepath.Join(pipeDir, meta.Name)
+
+	var written int64
+	for block := startBlock; block <= endBlock && remaining > 0; block += int64(numBlocks) {
+		last := block + int64(numBlocks) - 1
+		if last > endBlock {
+			last = endBlock
+		}
+
+		status := &blockStatus{bar: bar, offset: written}
+		stop := cancelDownloadOnInterrupt(ctx, f.allocationObj, f.downloadKey())
+		n, err := downloadRange(w, pipePath, remaining, status, func() error {
+			return f.downloadBlocks(pipePath, block, last, numBlocks, status)
+		})
+		stop()
+		if err != nil {
+			return err
+		}
+		written += n
+		remaining -= n
+		bar.Set64(written)
+	}
+
+	return nil
+}
+
+// resumeDownload continues the download of f, uploaded in chunks of chunkSize, to localPath from the first block which is not complete in it,
+// and checks the size of the local file against the remote meta once it is done, and its hash if verify is set.
+func resumeDownload(ctx context.Context, localPath string, f *remoteFile, chunkSize int64, numBlocks int, verify bool) error {
+	meta, err := f.getFileMeta()
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		localPath = filepath.Join(localPath, meta.Name)
+	}
+
+	file, err := os.OpenFile(localPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > meta.Size {
+		return fmt.Errorf("local file %s is larger than the remote file, %d > %d bytes", localPath, info.Size(), meta.Size)
+	}
+
+	if info.Size() < meta.Size {
+		// the last block of an interrupted download may be incomplete, it is downloaded again
+		blockSize := blockSizeOf(meta, chunkSize, f.allocationObj.DataShards)
+		nextBlock := info.Size()/blockSize + 1
+		offset := (nextBlock - 1) * blockSize
+		if err = file.Truncate(offset); err != nil {
+			return err
+		}
+		if _, err = file.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		fmt.Printf("Resuming download of %s at block %d, %d of %d bytes already downloaded\n", meta.Name, nextBlock, offset, meta.Size)
+		if err = streamDownload(ctx, file, f, meta, chunkSize, nextBlock, 0, numBlocks); err != nil {
+			return err
+		}
+		if err = file.Sync(); err != nil {
+			return err
+		}
+		if info, err = file.Stat(); err != nil {
+			return err
+		}
+	}
+
+	if info.Size() != meta.Size {
+		return fmt.Errorf("size of %s does not match the remote file, %d != %d bytes", localPath, info.Size(), meta.Size)
+	}
+	if verify {
+		if err = verifyDownload(localPath, meta.Path, meta.Hash, chunkSize, f.allocationObj.DataShards, len(meta.EncryptedKey) > 0); err != nil {
+			return err
+		}
+	}
+	fmt.Println("Download completed:", localPath)
+	return nil
+}
+
+// downloadRange starts the download of a range of blocks to path with start, status being its callback,
+// and writes at most limit bytes of the blocks to w. The blocks are read from a named pipe made at path while the sdk
+// writes them, so they are never stored on disk. Where named pipes can't be made, or if the sdk created path first,
+// they are downloaded to a scratch file at path, which is copied to w and removed.
+func downloadRange(w io.Writer, path string, limit int64, status *blockStatus, start func() error) (int64, error) {
+	status.wg.Add(1)
+	if err := start(); err != nil {
+		return 0, err
+	}
+	// the sdk checks path does not exist when the download starts, and creates it once blobbers agree on the file meta
+	if err := makeFifo(path); err != nil {
+		status.wg.Wait()
+		if status.err != nil {
+			return 0, status.err
+		}
+		return copyScratch(w, path, limit)
+	}
+	defer os.Remove(path)
+
+	var (
+		n       int64
+		copyErr error
+		copied  = make(chan struct{})
+	)
+	go func() {
+		defer close(copied)
+		n, copyErr = copyFifo(w, path, limit)
+	}()
+	status.wg.Wait()
+	if status.err != nil {
+		// the sdk may fail before it opens the pipe, which the reader waits for
+		for done := false; !done; {
+			select {
+			case <-copied:
+				done = true
+			case <-time.After(10 * time.Millisecond):
+				releaseFifo(path)
+			}
+		}
+		if copyErr != nil {
+			return n, copyErr
+		}
+		return n, status.err
+	}
+	<-copied
+	return n, copyErr
+}
+
+// copyFifo writes at most limit bytes read from the named pipe at path to w,
+// and reads the rest of what is written to the pipe so the writer is not blocked
+func copyFifo(w io.Writer, path string, limit int64) (int64, error) {
+	// opening the pipe waits for its writer
+	fifo, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer fifo.Close()
+
+	n, err := io.Copy(w, io.LimitReader(fifo, limit))
+	if err != nil {
+		return n, err
+	}
+	_, err = io.Copy(ioutil.Discard, fifo)
+	return n, err
+}
+
+// copyScratch writes at most limit bytes of the blocks downloaded to scratchPath to w, and removes scratchPath
+func copyScratch(w io.Writer, scratchPath string, limit int64) (int64, error) {
+	scratch, err := os.Open(scratchPath)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(scratchPath)
+	defer scratch.Close()
+
+	return io.Copy(w, io.LimitReader(scratch, limit))
+}
+
+// blockStatus is the sdk.StatusCallback of a range of blocks downloaded by streamDownload.
+// Progress is added to bar after offset bytes which were already written.
+type blockStatus struct {
+	bar    *pb.ProgressBar
+	offset int64
+
+	wg  sync.WaitGroup
+	err error
+}
+
+func (s *blockStatus) Started(allocationId, filePath string, op int, totalBytes int) {}
+
+func (s *blockStatus) InProgress(allocationId, filePath string, op int, completedBytes int, data []byte) {
+	s.bar.Set64(s.offset + int64(completedBytes))
+}
+
+func (s *blockStatus) Completed(allocationId, filePath string, filename string, mimetype string, size int, op int) {
+	s.wg.Done()
+}
+
+func (s *blockStatus) Error(allocationID string, filePath string, op int, err error) {
+	s.err = err
+	if err == nil {
+		s.err = errors.New("download failed")
+	}
+	s.wg.Done()
+}
+
+func (s *blockStatus) CommitMetaCompleted(request, response string, err error) {
+	s.err = err
+	s.wg.Done()
+}
+
+func (s *blockStatus) RepairCompleted(filesRepaired int) {
+	s.wg.Done()
+}
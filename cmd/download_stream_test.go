@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"gopkg.in/cheggaaa/pb.v1"
+)
+
+// fakeBlockDownload writes data to path as the sdk downloads a range of blocks: it checks path does not exist,
+// creates it later in another goroutine, writes data in pieces and calls Completed before it closes the file.
+// If created is set, path is created before fakeBlockDownload returns, as the sdk may do before the pipe is made.
+type fakeBlockDownload struct {
+	data    []byte
+	created bool
+	failErr error // the download fails with failErr before path is created
+
+	isPipe bool // path was a named pipe when it was opened
+}
+
+func (d *fakeBlockDownload) start(path string, status *blockStatus) error {
+	if _, err := os.Stat(path); err == nil {
+		return errors.New("local file already exists")
+	}
+	var file *os.File
+	if d.created {
+		var err error
+		if file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+			return err
+		}
+	}
+	go func() {
+		if d.failErr != nil {
+			status.Error("", path, 0, d.failErr)
+			return
+		}
+		if file == nil {
+			time.Sleep(20 * time.Millisecond)
+			if info, err := os.Stat(path); err == nil {
+				d.isPipe = info.Mode()&os.ModeNamedPipe != 0
+			}
+			var err error
+			if file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+				status.Error("", path, 0, err)
+				return
+			}
+		}
+		defer file.Close()
+		for p := d.data; len(p) > 0; {
+			n := 16 * 1024
+			if n > len(p) {
+				n = len(p)
+			}
+			if _, err := file.Write(p[:n]); err != nil {
+				os.Remove(path)
+				status.Error("", path, 0, err)
+				return
+			}
+			p = p[n:]
+			status.InProgress("", path, 0, len(d.data)-len(p), nil)
+		}
+		status.Completed("", path, "", "", len(d.data), 0)
+	}()
+	return nil
+}
+
+// runDownloadRange runs downloadRange of d, failing the test if it does not return
+func runDownloadRange(t *testing.T, w io.Writer, limit int64, d *fakeBlockDownload) (string, int64, error) {
+	path := filepath.Join(t.TempDir(), "file")
+	bar := pb.New64(0)
+	bar.Output = ioutil.Discard
+	status := &blockStatus{bar: bar}
+
+	var (
+		n    int64
+		err  error
+		done = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		n, err = downloadRange(w, path, limit, status, func() error { return d.start(path, status) })
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("downloadRange does not return")
+	}
+	return path, n, err
+}
+
+// failingWriter fails every write after the first
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.writes++; w.writes > 1 {
+		return 0, errors.New("broken pipe")
+	}
+	return len(p), nil
+}
+
+func TestDownloadRange(t *testing.T) {
+	data := make([]byte, 300*1024)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	// the last block is padded, the padding is not written
+	limit := int64(len(data) - 1000)
+
+	t.Run("through a named pipe", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("no named pipes on windows")
+		}
+		d := &fakeBlockDownload{data: data}
+		var out bytes.Buffer
+		path, n, err := runDownloadRange(t, &out, limit, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !d.isPipe {
+			t.Error("the blocks were downloaded to a file")
+		}
+		if n != limit || !bytes.Equal(out.Bytes(), data[:limit]) {
+			t.Errorf("wrote %d bytes, want the first %d bytes of the blocks", n, limit)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("the pipe is not removed: %v", err)
+		}
+	})
+
+	t.Run("through a scratch file created by the sdk", func(t *testing.T) {
+		var out bytes.Buffer
+		path, n, err := runDownloadRange(t, &out, limit, &fakeBlockDownload{data: data, created: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != limit || !bytes.Equal(out.Bytes(), data[:limit]) {
+			t.Errorf("wrote %d bytes, want the first %d bytes of the blocks", n, limit)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("the scratch file is not removed: %v", err)
+		}
+	})
+
+	t.Run("download fails before the pipe is opened", func(t *testing.T) {
+		failErr := errors.New("no minimum consensus for file meta data of file")
+		_, _, err := runDownloadRange(t, ioutil.Discard, limit, &fakeBlockDownload{data: data, failErr: failErr})
+		if err != failErr {
+			t.Errorf("error is %v, want %v", err, failErr)
+		}
+	})
+
+	t.Run("writer fails", func(t *testing.T) {
+		_, _, err := runDownloadRange(t, &failingWriter{}, limit, &fakeBlockDownload{data: data})
+		if err == nil || err.Error() != "broken pipe" {
+			t.Errorf("error is %v, want the error of the writer", err)
+		}
+	})
+}
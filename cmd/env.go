@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/0chain/gosdk/core/conf"
+	"github.com/spf13/pflag"
+)
+
+// envPrefix is the prefix of the environment variables of the root flags and the config.yaml keys
+const envPrefix = "ZBOX_"
+
+// walletJSONEnv is the environment variable a wallet json is read from instead of the wallet file
+const walletJSONEnv = "ZBOX_WALLET_JSON"
+
+// allocationEnv is the environment variable of the allocation of commands run without --allocation
+const allocationEnv = "ZBOX_ALLOCATION"
+
+var camelCaseRegexp = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// envName returns the environment variable of a root flag or config.yaml key,
+// ZBOX_ and the name in upper snake case: configDir is ZBOX_CONFIG_DIR, log-file is ZBOX_LOG_FILE
+func envName(name string) string {
+	name = camelCaseRegexp.ReplaceAllString(name, "${1}_${2}")
+	return envPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+// lookupEnv returns the environment variable of a root flag or config.yaml key, if it is set and not empty
+func lookupEnv(name string) (string, bool) {
+	v := os.Getenv(envName(name))
+	return v, len(v) > 0
+}
+
+// applyFlagEnv sets the root flags from their environment variables.
+// It runs before the arguments are parsed, so flags given on the command line override them.
+func applyFlagEnv() error {
+	var err error
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		v, ok := lookupEnv(f.Name)
+		if !ok || err != nil {
+			return
+		}
+		if e := f.Value.Set(v); e != nil {
+			err = fmt.Errorf("invalid %s %q: %v", envName(f.Name), v, e)
+		}
+	})
+	return err
+}
+
+// envSource returns the source of a root flag which is not given but set by its environment variable,
+// or of a config.yaml key set by its environment variable, empty otherwise
+func envSource(name string) string {
+	if f := rootCmd.PersistentFlags().Lookup(name); f != nil && f.Changed {
+		return ""
+	}
+	if _, ok := lookupEnv(name); ok {
+		return "env " + envName(name)
+	}
+	return ""
+}
+
+// configReader reads the keys of config.yaml, overridden by their environment variables.
+// A list set by an environment variable is comma separated.
+type configReader struct {
+	file map[string]interface{}
+}
+
+func (r configReader) get(key string) (interface{}, bool) {
+	if v, ok := lookupEnv(key); ok {
+		return v, true
+	}
+	v, ok := r.file[key]
+	return v, ok && v != nil
+}
+
+func (r configReader) GetString(key string) string {
+	v, ok := r.get(key)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func (r configReader) GetInt(key string) int {
+	v, _ := r.get(key)
+	switch n := v.(type) {
+	case int:
+		return n
+	case float64:
+		return int(n)
+	case string:
+		i, _ := strconv.Atoi(strings.TrimSpace(n))
+		return i
+	}
+	return 0
+}
+
+func (r configReader) GetStringSlice(key string) []string {
+	v, _ := r.get(key)
+	var list []string
+	switch s := v.(type) {
+	case string:
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); len(item) > 0 {
+				list = append(list, item)
+			}
+		}
+	case []interface{}:
+		for _, item := range s {
+			list = append(list, fmt.Sprint(item))
+		}
+	}
+	return list
+}
+
+// loadConfig reads config.yaml in configDir, overridden by the ZBOX_ environment variables of its keys.
+// The file is optional when ZBOX_BLOCK_WORKER is set, so containers can be configured by environment only.
+// It returns the keys of the file too, to tell the values of the file from the defaults.
+func loadConfig(configDir string) (conf.Config, map[string]interface{}, error) {
+	raw, err := readYAMLFile(getConfigFilePath(configDir))
+	if os.IsNotExist(err) {
+		if _, ok := lookupEnv("block_worker"); ok {
+			raw, err = make(map[string]interface{}), nil
+		}
+	}
+	if err != nil {
+		return conf.Config{}, nil, err
+	}
+	cfg, err := conf.LoadConfig(configReader{raw})
+	return cfg, raw, err
+}
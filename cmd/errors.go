@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// errorKind classifies the errors commands return, each kind has its own exit code, see exitCodes
+type errorKind string
+
+const (
+	kindGeneral      errorKind = "error"
+	kindUsage        errorKind = "usage"
+	kindConfig       errorKind = "config"
+	kindNotFound     errorKind = "not_found"
+	kindFunds        errorKind = "insufficient_funds"
+	kindNetwork      errorKind = "network"
+	kindIntegrity    errorKind = "integrity"
+	kindInterrupted  errorKind = "interrupted"
+	kindPartialFails errorKind = "partial_failure"
+)
+
+// exitCodes of zbox by error kind, documented in the README. They must not change between versions.
+var exitCodes = map[errorKind]int{
+	kindGeneral:      1,
+	kindUsage:        2,
+	kindConfig:       3,
+	kindNotFound:     4,
+	kindFunds:        5,
+	kindNetwork:      6,
+	kindIntegrity:    7,
+	kindPartialFails: 8,
+	kindInterrupted:  exitCodeInterrupted,
+}
+
+// error formats of the root --error_format flag
+const (
+	errorFormatText = "text"
+	errorFormatJSON = "json"
+)
+
+var errorFormat string
+
+// commandStarted is set once flags and arguments are parsed and the command runs,
+// errors returned before are usage errors of cobra
+var commandStarted bool
+
+// cmdError is an error returned by a command, handled by handleError
+type cmdError struct {
+	kind errorKind
+	msg  string
+	err  error
+}
+
+func (e *cmdError) Error() string {
+	switch {
+	case e.err == nil:
+		return e.msg
+	case len(e.msg) == 0:
+		return e.err.Error()
+	}
+	return e.msg + " " + e.err.Error()
+}
+
+func (e *cmdError) Unwrap() error {
+	return e.err
+}
+
+// newError builds a cmdError of kind, the message is v joined by spaces the same way PrintError prints it.
+// The last error in v is wrapped by the cmdError.
+func newError(kind errorKind, v ...interface{}) *cmdError {
+	e := &cmdError{kind: kind}
+	msg := make([]interface{}, 0, len(v))
+	for _, val := range v {
+		if err, ok := val.(error); ok && err != nil {
+			e.err = err
+			continue
+		}
+		msg = append(msg, val)
+	}
+	e.msg = strings.TrimSpace(fmt.Sprintln(msg...))
+	return e
+}
+
+// commandError is a failure of a command, its kind is classified from the error in v, if any
+func commandError(v ...interface{}) error {
+	e := newError(kindGeneral, v...)
+	if e.err != nil {
+		e.kind = classifyError(e.err)
+	}
+	return e
+}
+
+// usageError is an invalid or missing flag or argument
+func usageError(v ...interface{}) error {
+	return newError(kindUsage, v...)
+}
+
+// configError is an invalid config, network, profile or wallet
+func configError(v ...interface{}) error {
+	return newError(kindConfig, v...)
+}
+
+// notFoundError is an allocation, file, blobber or pool which does not exist
+func notFoundError(v ...interface{}) error {
+	return newError(kindNotFound, v...)
+}
+
+// interruptedError returns an error if ctx was canceled by a signal
+func interruptedError(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return newError(kindInterrupted, "Interrupted.")
+	}
+	return nil
+}
+
+// transferError is commandError, or the interrupted error if the transfer failed because ctx was canceled
+func transferError(ctx context.Context, v ...interface{}) error {
+	if err := interruptedError(ctx); err != nil {
+		return err
+	}
+	return commandError(v...)
+}
+
+// transferFailedError is returned by multi-file transfers some files of which failed,
+// or is the interrupted error if they failed because ctx was canceled
+func transferFailedError(ctx context.Context, failed int) error {
+	if err := interruptedError(ctx); err != nil {
+		return err
+	}
+	return newError(kindPartialFails, fmt.Sprintf("%d file(s) failed", failed))
+}
+
+// classifyError finds the kind of err. Errors of the sdk are only text, they are classified by known phrases.
+func classifyError(err error) errorKind {
+	var ce *cmdError
+	if errors.As(err, &ce) {
+		return ce.kind
+	}
+	var ie *integrityError
+	if errors.As(err, &ie) {
+		return kindIntegrity
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, errTransferCanceled) {
+		return kindInterrupted
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, c := range errorPhrases {
+		for _, phrase := range c.phrases {
+			if strings.Contains(msg, phrase) {
+				return c.kind
+			}
+		}
+	}
+	return kindGeneral
+}
+
+// errorPhrases are checked in order, the first match decides the kind of an sdk error
+var errorPhrases = []struct {
+	kind    errorKind
+	phrases []string
+}{
+	{kindFunds, []string{"insufficient", "not enough tokens", "not enough balance", "balance is too low", "lack of tokens", "no tokens"}},
+	// keys missing from the wallet, before "does not exist" of not found
+	{kindConfig, []string{"private key does not exist", "public key does not exist", "wallet info not found"}},
+	{kindNotFound, []string{"not found", "not_found", "no such file", "does not exist", "doesn't exist"}},
+	{kindNetwork, []string{"connection refused", "no such host", "timeout", "deadline exceeded", "unreachable",
+		"network details", "consensus", "dial tcp", "connection reset"}},
+}
+
+// errorOutput is the json error object printed on stderr with --error_format json
+type errorOutput struct {
+	Error struct {
+		Kind     errorKind `json:"kind"`
+		ExitCode int       `json:"exit_code"`
+		Message  string    `json:"message"`
+	} `json:"error"`
+}
+
+// handleError prints err on stderr in the --error_format, and returns the exit code of its kind
+func handleError(err error) int {
+	kind := classifyError(err)
+	var ce *cmdError
+	if !commandStarted && !errors.As(err, &ce) {
+		// errors of flags, arguments and unknown commands, whatever they say, like an invalid --timeout
+		kind = kindUsage
+	}
+	code := exitCodes[kind]
+
+	if errorFormat == errorFormatJSON {
+		var out errorOutput
+		out.Error.Kind = kind
+		out.Error.ExitCode = code
+		out.Error.Message = err.Error()
+		buf, _ := json.Marshal(&out)
+		fmt.Fprintln(os.Stderr, string(buf))
+		return code
+	}
+
+	PrintError(err.Error())
+	return code
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	thrown "github.com/0chain/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func TestMissingRequiredFlagIsUsageError(t *testing.T) {
+	var ran bool
+	requiredCmd := &cobra.Command{
+		Use:         "required-flag-test",
+		Annotations: map[string]string{annotationOffline: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ran = true
+			return nil
+		},
+	}
+	requiredCmd.Flags().String("allocation", "", "Allocation ID")
+	requiredCmd.Flags().String("remotepath", "", "Remote path")
+	requiredCmd.MarkFlagRequired("allocation")
+	requiredCmd.MarkFlagRequired("remotepath")
+	rootCmd.AddCommand(requiredCmd)
+	rootCmd.SetOut(ioutil.Discard)
+	rootCmd.SetErr(ioutil.Discard)
+	defer func() {
+		rootCmd.RemoveCommand(requiredCmd)
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		cDir = ""
+		commandStarted = false
+	}()
+
+	noDefault := t.TempDir()
+	withDefault := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(withDefault, defaultAllocationFile), []byte(testDefaultAllocation), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		configDir string
+		args      []string
+		wantRun   bool
+	}{
+		{"no flags", noDefault, nil, false},
+		{"no remotepath", withDefault, []string{"--allocation", testDefaultAllocation}, false},
+		{"no allocation and no default allocation", noDefault, []string{"--remotepath", "/a.txt"}, false},
+		// the default allocation is applied before required flags are checked
+		{"default allocation", withDefault, []string{"--remotepath", "/a.txt"}, true},
+	}
+	for _, tt := range tests {
+		ran, commandStarted = false, false
+		requiredCmd.Flags().VisitAll(func(f *pflag.Flag) {
+			f.Value.Set(f.DefValue)
+			f.Changed = false
+		})
+		rootCmd.SetArgs(append([]string{"required-flag-test", "--configDir", tt.configDir}, tt.args...))
+		err := rootCmd.Execute()
+		if tt.wantRun {
+			if err != nil || !ran {
+				t.Errorf("%s: command did not run: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || ran {
+			t.Errorf("%s: command ran without its required flags", tt.name)
+			continue
+		}
+		if code := handleError(err); code != exitCodes[kindUsage] {
+			t.Errorf("%s: missing required flag exited %d, want %d", tt.name, code, exitCodes[kindUsage])
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want errorKind
+	}{
+		// errors of the sdk, as commands return them
+		{"file not found", commandError("Error fetching the file meta:", thrown.New("", "File not found for the given remotepath")), kindNotFound},
+		{"object not found", commandError("Rename failed.", thrown.New("file_not_found", "Object to rename not found in blobber")), kindNotFound},
+		{"allocation does not exist", commandError("Error fetching the allocation", errors.New("allocation does not exist")), kindNotFound},
+		{"insufficient balance", commandError("Failed to lock tokens.", errors.New("insufficient balance to lock")), kindFunds},
+		{"not enough tokens", commandError("Error creating allocation:", errors.New("not enough tokens to honor the allocation")), kindFunds},
+		{"commit consensus", commandError("Upload failed.", thrown.New("commit_consensus_failed", "Upload failed as there was no commit consensus")), kindNetwork},
+		{"consensus rate", commandError("Upload failed.", fmt.Errorf("Upload failed: Consensus_rate:%f, expected:%f", 0.5, 1.0)), kindNetwork},
+		{"balance consensus", commandError(thrown.New("", "get balance failed. consensus not reached")), kindNetwork},
+		{"connection refused", commandError(errors.New(`Get "http://localhost:5051/v1/file/meta": dial tcp 127.0.0.1:5051: connect: connection refused`)), kindNetwork},
+		{"no such host", commandError(errors.New("dial tcp: lookup one.devnet-0chain.net: no such host")), kindNetwork},
+		{"deadline", commandError(context.DeadlineExceeded), kindNetwork},
+		{"missing private key", commandError(thrown.New("raw_sign", "private key does not exists for signing")), kindConfig},
+		{"missing wallet", commandError(thrown.New("", "wallet info not found. set wallet info")), kindConfig},
+		{"other", commandError("Error:", errors.New("invalid attributes")), kindGeneral},
+		// errors of zbox
+		{"usage", usageError("Error: allocation flag is missing"), kindUsage},
+		{"kind is kept when wrapped", fmt.Errorf("retry: %w", notFoundError("Error: no such profile")), kindNotFound},
+		{"explicit kind wins over the text", newError(kindNetwork, "Error: 0/1 miners are up, not found"), kindNetwork},
+		{"integrity", commandError(&integrityError{RemotePath: "/a", LocalHash: "x", RemoteHash: "y"}), kindIntegrity},
+		{"canceled", commandError("Download failed.", context.Canceled), kindInterrupted},
+		{"canceled transfer", commandError(errTransferCanceled), kindInterrupted},
+		{"interrupted", newError(kindInterrupted, "Interrupted."), kindInterrupted},
+		{"partial failure", newError(kindPartialFails, "2 file(s) failed"), kindPartialFails},
+	}
+	for _, tt := range tests {
+		if got := classifyError(tt.err); got != tt.want {
+			t.Errorf("%s: %q is %s, want %s", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCobraErrorsAreUsageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown command", []string{"no-such-command"}},
+		{"unknown flag", []string{"network", "status", "--no-such-flag"}},
+		{"invalid flag value mentioning a network phrase", []string{"network", "status", "--timeout", "soon"}},
+		{"too many arguments", []string{"network", "status", "extra"}},
+	}
+	rootCmd.SetOut(ioutil.Discard)
+	defer func() {
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+	}()
+	for _, tt := range tests {
+		commandStarted = false
+		rootCmd.SetArgs(tt.args)
+		err := rootCmd.Execute()
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+			continue
+		}
+		if code := handleError(err); code != exitCodes[kindUsage] {
+			t.Errorf("%s: %q exited %d, want %d", tt.name, err, code, exitCodes[kindUsage])
+		}
+	}
+	commandStarted = false
+}
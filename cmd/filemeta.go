@@ -16,43 +16,35 @@ var filemetaCmd = &cobra.Command{
 	Short: "get meta data of files from blobbers",
 	Long:  `get meta data of files from blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags() // fflags is a *flag.FlagSet
 		if fflags.Changed("remotepath") == false && fflags.Changed("authticket") == false {
-			PrintError("Error: remotepath / authticket flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath / authticket flag is missing")
 		}
 
 		remotepath := cmd.Flag("remotepath").Value.String()
 		authticket := cmd.Flag("authticket").Value.String()
 		lookuphash := cmd.Flag("lookuphash").Value.String()
-		doJSON, _ := cmd.Flags().GetBool("json")
 		if len(remotepath) == 0 && (len(authticket) == 0) {
-			PrintError("Error: remotepath / authticket / lookuphash flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath / authticket / lookuphash flag is missing")
 		}
 
 		if len(remotepath) > 0 {
 			if fflags.Changed("allocation") == false { // check if the flag "path" is set
-				PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-				os.Exit(1)                                      // and return
+				return usageError("Error: allocation flag is missing")
 			}
 			allocationID := cmd.Flag("allocation").Value.String()
 			allocationObj, err := sdk.GetAllocation(allocationID)
 			if err != nil {
-				PrintError("Error fetching the allocation", err)
-				os.Exit(1)
+				return commandError("Error fetching the allocation", err)
 			}
 			remotepath := cmd.Flag("remotepath").Value.String()
 			ref, err := allocationObj.GetFileMeta(remotepath)
 			if err != nil {
-				PrintError(err.Error())
-				os.Exit(1)
+				return commandError(err)
 			}
 
-			if doJSON {
-				util.PrintJSON(ref)
-			} else {
+			printOutput(cmd, newFileMetaOutput(ref), metaCSVHeader, metaCSVData(ref), func() {
 				header := []string{"Type", "Name", "Path", "Lookup Hash"}
 				data := make([][]string, 1)
 				data[0] = []string{ref.Type, ref.Name, ref.Path, ref.LookupHash}
@@ -64,30 +56,25 @@ var filemetaCmd = &cobra.Command{
 				}
 
 				util.WriteTable(os.Stdout, header, []string{}, data)
-			}
+			})
 		} else if len(authticket) > 0 {
 			allocationObj, err := sdk.GetAllocationFromAuthTicket(authticket)
 			if err != nil {
-				PrintError("Error fetching the allocation", err)
-				os.Exit(1)
+				return commandError("Error fetching the allocation", err)
 			}
 			at := sdk.InitAuthTicket(authticket)
 			if len(lookuphash) == 0 {
 				lookuphash, err = at.GetLookupHash()
 				if err != nil {
-					PrintError("Error getting the lookuphash from authticket", err)
-					os.Exit(1)
+					return commandError("Error getting the lookuphash from authticket", err)
 				}
 			}
 
 			ref, err := allocationObj.GetFileMetaFromAuthTicket(authticket, lookuphash)
 			if err != nil {
-				PrintError(err.Error())
-				os.Exit(1)
+				return commandError(err)
 			}
-			if doJSON {
-				util.PrintJSON(ref)
-			} else {
+			printOutput(cmd, newFileMetaOutput(ref), metaCSVHeader, metaCSVData(ref), func() {
 				header := []string{"Type", "Name", "Lookup Hash"}
 				data := make([][]string, 1)
 				data[0] = []string{ref.Type, ref.Name, ref.LookupHash}
@@ -98,18 +85,67 @@ var filemetaCmd = &cobra.Command{
 					data[0] = append(data[0], dataFile...)
 				}
 				util.WriteTable(os.Stdout, header, []string{}, data)
-			}
+			})
 		}
-		return
+		return nil
 	},
 }
 
+// fileMetaOutput is sdk.ConsolidatedFileMeta with the json names of the file refs of blobbers, as meta prints it
+type fileMetaOutput struct {
+	Type            string                  `json:"type"`
+	Name            string                  `json:"name"`
+	Path            string                  `json:"path"`
+	LookupHash      string                  `json:"lookup_hash"`
+	Size            int64                   `json:"size"`
+	MimeType        string                  `json:"mime_type"`
+	Hash            string                  `json:"hash"`
+	ActualFileSize  int64                   `json:"actual_file_size"`
+	ActualNumBlocks int64                   `json:"actual_num_blocks"`
+	EncryptedKey    string                  `json:"encrypted_key"`
+	CommitMetaTxns  []fileref.CommitMetaTxn `json:"commit_meta_txns"`
+	Collaborators   []fileref.Collaborator  `json:"collaborators"`
+	Attributes      fileref.Attributes      `json:"attributes"`
+}
+
+func newFileMetaOutput(ref *sdk.ConsolidatedFileMeta) *fileMetaOutput {
+	out := &fileMetaOutput{
+		Type:            ref.Type,
+		Name:            ref.Name,
+		Path:            ref.Path,
+		LookupHash:      ref.LookupHash,
+		Size:            ref.Size,
+		MimeType:        ref.MimeType,
+		Hash:            ref.Hash,
+		ActualFileSize:  ref.ActualFileSize,
+		ActualNumBlocks: ref.ActualNumBlocks,
+		EncryptedKey:    ref.EncryptedKey,
+		CommitMetaTxns:  ref.CommitMetaTxns,
+		Collaborators:   ref.Collaborators,
+		Attributes:      ref.Attributes,
+	}
+	if out.CommitMetaTxns == nil {
+		out.CommitMetaTxns = []fileref.CommitMetaTxn{}
+	}
+	if out.Collaborators == nil {
+		out.Collaborators = []fileref.Collaborator{}
+	}
+	return out
+}
+
+// metaCSVHeader is the csv header of meta, see metaCSVData
+var metaCSVHeader = []string{"type", "name", "path", "lookup_hash", "size", "mime_type", "hash"}
+
+func metaCSVData(ref *sdk.ConsolidatedFileMeta) [][]string {
+	return [][]string{{ref.Type, ref.Name, ref.Path, ref.LookupHash, strconv.FormatInt(ref.Size, 10), ref.MimeType, ref.Hash}}
+}
+
 func init() {
 	rootCmd.AddCommand(filemetaCmd)
 	filemetaCmd.PersistentFlags().String("allocation", "", "Allocation ID")
 	filemetaCmd.PersistentFlags().String("remotepath", "", "Remote path to list from")
 	filemetaCmd.PersistentFlags().String("authticket", "", "Auth ticket fot the file to download if you dont own it")
 	filemetaCmd.PersistentFlags().String("lookuphash", "", "The remote lookuphash of the object retrieved from the list")
-	filemetaCmd.Flags().Bool("json", false, "pass this option to print response as json data")
+	filemetaCmd.Flags().Bool("json", false, "pass this option to print response as json data, same as --output json")
 	filemetaCmd.MarkFlagRequired("allocation")
 }
@@ -15,33 +15,24 @@ var statsCmd = &cobra.Command{
 	Short: "stats for file from blobbers",
 	Long:  `stats for file from blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags()                      // fflags is a *flag.FlagSet
 		if fflags.Changed("allocation") == false { // check if the flag "path" is set
-			PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-			os.Exit(1)                                      // and return
+			return usageError("Error: allocation flag is missing")
 		}
 		if fflags.Changed("remotepath") == false {
-			PrintError("Error: remotepath flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath flag is missing")
 		}
 		allocationID := cmd.Flag("allocation").Value.String()
-		doJSON, _ := cmd.Flags().GetBool("json")
 
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation", err)
 		}
 		remotepath := cmd.Flag("remotepath").Value.String()
 		ref, err := allocationObj.GetFileStats(remotepath)
 		if err != nil {
-			PrintError(err.Error())
-			os.Exit(1)
-		}
-		if doJSON {
-			util.PrintJSON(ref)
-			return
+			return commandError(err)
 		}
 		header := []string{"Blobber", "Name", "Path", "Size", "Uploads", "Block Downloads", "Challenges", "Blockchain Aware"}
 		data := make([][]string, 0)
@@ -55,16 +46,21 @@ var statsCmd = &cobra.Command{
 			}
 		}
 
-		util.WriteTable(os.Stdout, header, []string{}, data)
-		return
+		printOutput(cmd, ref, statsCSVHeader, data, func() {
+			util.WriteTable(os.Stdout, header, []string{}, data)
+		})
+		return nil
 	},
 }
 
+// statsCSVHeader is the csv header of stats, in the same order as its table
+var statsCSVHeader = []string{"blobber_id", "name", "path", "size", "num_of_updates", "num_of_block_downloads", "num_of_challenges", "blockchain_aware"}
+
 func init() {
 	rootCmd.AddCommand(statsCmd)
 	statsCmd.PersistentFlags().String("allocation", "", "Allocation ID")
 	statsCmd.PersistentFlags().String("remotepath", "", "Remote path to list from")
 	statsCmd.MarkFlagRequired("allocation")
 	statsCmd.MarkFlagRequired("remotepath")
-	statsCmd.Flags().Bool("json", false, "pass this option to print response as json data")
+	statsCmd.Flags().Bool("json", false, "pass this option to print response as json data, same as --output json")
 }
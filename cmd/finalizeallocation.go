@@ -11,19 +11,20 @@ import (
 func isFinalized(allocID string) (ok bool, err error) {
 	var alloc *sdk.Allocation
 	if alloc, err = sdk.GetAllocation(allocID); err != nil {
-		return false, fmt.Errorf("can't get allocation from sharders: %v", err)
+		return false, fmt.Errorf("can't get allocation from sharders: %w", err)
 	}
 	return alloc.Finalized, nil
 }
 
-func allocShouldNotBeFinalized(allocID string) {
+func allocShouldNotBeFinalized(allocID string) error {
 	var ok, err = isFinalized(allocID)
 	if err != nil {
-		log.Fatalf("can't get allocation from sharders: %v", err)
+		return commandError(err)
 	}
 	if ok {
-		log.Fatal("allocation already finalized")
+		return commandError("allocation already finalized")
 	}
+	return nil
 }
 
 // finiAllocationCmd used to change allocation size and expiration
@@ -34,30 +35,35 @@ var finiAllocationCmd = &cobra.Command{
 blobbers of the allocation. It moves all tokens have to be moved between pools
 and empties write pool moving left tokens to client.`,
 	Args: cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var flags = cmd.Flags()
 
 		if flags.Changed("allocation") == false {
-			log.Fatal("Error: allocation flag is missing")
+			return usageError("Error: allocation flag is missing")
 		}
 
 		allocID, err := flags.GetString("allocation")
 		if err != nil {
-			log.Fatal("invalid 'allocation' flag: ", err)
+			return usageError("invalid 'allocation' flag: ", err)
 		}
 
 		// check out allocation first
-		allocShouldNotBeFinalized(allocID)
+		if err = allocShouldNotBeFinalized(allocID); err != nil {
+			return err
+		}
 
 		txnHash, err := sdk.FinalizeAllocation(allocID)
 		if err != nil {
 			// check again, a blobber can finalize it
-			allocShouldNotBeFinalized(allocID)
+			if ferr := allocShouldNotBeFinalized(allocID); ferr != nil {
+				return ferr
+			}
 			// finalizing error
-			log.Fatal("Error finalizing allocation:", err)
+			return commandError("Error finalizing allocation:", err)
 		}
 		// success
 		log.Print("Allocation finalized with txId : " + txnHash)
+		return nil
 	},
 }
 
@@ -71,23 +77,24 @@ of blobbers, it can't be used. Thus, the blobbers will not receive their
 min_lock_demand. Other aspects of the cancellation follows the finalize
 allocation flow.`,
 	Args: cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var flags = cmd.Flags()
 
 		if flags.Changed("allocation") == false {
-			log.Fatal("Error: allocation flag is missing")
+			return usageError("Error: allocation flag is missing")
 		}
 
 		allocID, err := flags.GetString("allocation")
 		if err != nil {
-			log.Fatal("invalid 'allocation' flag: ", err)
+			return usageError("invalid 'allocation' flag: ", err)
 		}
 
 		txnHash, err := sdk.CancelAllocation(allocID)
 		if err != nil {
-			log.Fatal("Error creating allocation:", err)
+			return commandError("Error creating allocation:", err)
 		}
 		log.Print("Allocation canceled with txId : " + txnHash)
+		return nil
 	},
 }
 
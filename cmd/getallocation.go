@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"time"
 
@@ -11,7 +10,6 @@ import (
 	"github.com/0chain/gosdk/zboxcore/fileref"
 	. "github.com/0chain/gosdk/zboxcore/logger"
 	"github.com/0chain/gosdk/zboxcore/sdk"
-	"github.com/0chain/zboxcli/util"
 
 	"github.com/spf13/cobra"
 )
@@ -36,135 +34,149 @@ func uploadCostForBlobber(price float64, size int64, data, parity int) (
 	return common.Balance(price * sizeInGB(ps))
 }
 
-func downloadCostFor1GB(alloc *sdk.Allocation) (cost common.Balance) {
+// downloadCostForSize returns the cost to download size bytes of a file, and the blocks requested
+func downloadCostForSize(alloc *sdk.Allocation, size int64) (cost common.Balance, blocks int64) {
 	var (
-		ps   = perShard(1*GB, alloc.DataShards, alloc.ParityShards)
-		cps  = (ps + fileref.CHUNK_SIZE - 1) / fileref.CHUNK_SIZE
-		size float64 // GB
+		ps = perShard(size, alloc.DataShards, alloc.ParityShards)
+		gb float64 // GB
 	)
+	blocks = (ps + fileref.CHUNK_SIZE - 1) / fileref.CHUNK_SIZE
 	// the Go SDK requests block by 10
-	if cps%10 > 0 {
-		cps = ((cps/10)*10 + 10)
+	if blocks%10 > 0 {
+		blocks = ((blocks/10)*10 + 10)
 	}
-	size = sizeInGB(cps * fileref.CHUNK_SIZE)
+	gb = sizeInGB(blocks * fileref.CHUNK_SIZE)
 	for _, d := range alloc.BlobberDetails {
-		cost += common.Balance(float64(d.Terms.ReadPrice) * size)
+		cost += common.Balance(float64(d.Terms.ReadPrice) * gb)
+	}
+	if len(alloc.BlobberDetails) > 0 {
+		cost = cost / common.Balance(len(alloc.BlobberDetails))
 	}
-	cost = cost / common.Balance(len(alloc.BlobberDetails))
 	return
 }
 
-func uploadCostFor1GB(alloc *sdk.Allocation) (cost common.Balance) {
+func downloadCostFor1GB(alloc *sdk.Allocation) (cost common.Balance) {
+	cost, _ = downloadCostForSize(alloc, 1*GB)
+	return
+}
+
+// uploadCostForSize returns the cost to upload size bytes of a file, per time unit of the allocation
+func uploadCostForSize(alloc *sdk.Allocation, size int64) (cost common.Balance) {
 	for _, d := range alloc.BlobberDetails {
-		cost += uploadCostForBlobber(float64(d.Terms.WritePrice), 1*GB,
+		cost += uploadCostForBlobber(float64(d.Terms.WritePrice), size,
 			alloc.DataShards, alloc.ParityShards)
 	}
 	return
 }
 
+func uploadCostFor1GB(alloc *sdk.Allocation) (cost common.Balance) {
+	return uploadCostForSize(alloc, 1*GB)
+}
+
 // getallocationCmd represents the get allocation info command
 var getallocationCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Gets the allocation info",
 	Long:  `Gets the allocation info`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags()                      // fflags is a *flag.FlagSet
 		if fflags.Changed("allocation") == false { // check if the flag "path" is set
-			PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-			os.Exit(1)                                      // and os.Exit(1)
+			return usageError("Error: allocation flag is missing")
 		}
 		allocationID := cmd.Flag("allocation").Value.String()
-		doJSON, _ := cmd.Flags().GetBool("json")
 		alloc, err := sdk.GetAllocation(allocationID)
 		if err != nil {
 			Logger.Error("Error fetching the allocation", err)
-			log.Fatal("Error fetching/verifying the allocation")
+			return commandError("Error fetching/verifying the allocation")
 		}
-		if doJSON {
-			util.PrintJSON(alloc)
-			return
-		}
-		var getBaseURL = func(bid string, bs []*blockchain.StorageNode) string {
-			for _, b := range bs {
-				if b.ID == bid {
-					return b.Baseurl
-				}
+		printOutput(cmd, alloc, allocationCSVHeader, [][]string{allocationCSVRow(alloc)}, func() {
+			printAllocation(alloc)
+		})
+		return nil
+	},
+}
+
+// printAllocation prints alloc for table output of get
+func printAllocation(alloc *sdk.Allocation) {
+	var getBaseURL = func(bid string, bs []*blockchain.StorageNode) string {
+		for _, b := range bs {
+			if b.ID == bid {
+				return b.Baseurl
 			}
-			return "(not found)"
 		}
+		return "(not found)"
+	}
 
-		var priceRangeString = func(pr sdk.PriceRange) string {
-			return fmt.Sprintf("%s-%s", common.Balance(pr.Min), common.Balance(pr.Max))
-		}
+	var priceRangeString = func(pr sdk.PriceRange) string {
+		return fmt.Sprintf("%s-%s", common.Balance(pr.Min), common.Balance(pr.Max))
+	}
 
-		fmt.Println("allocation:")
-		fmt.Println("  id:             ", alloc.ID)
-		fmt.Println("  tx:             ", alloc.Tx, "(latest create/update allocation transaction hash)")
-		fmt.Println("  data_shards:    ", alloc.DataShards)
-		fmt.Println("  parity_shards:  ", alloc.ParityShards)
-		fmt.Println("  size:           ", common.Size(alloc.Size))
-		fmt.Println("  expiration_date:", common.Timestamp(alloc.Expiration).ToTime())
-		fmt.Println("  immutable:      ", alloc.IsImmutable)
-		fmt.Println("  blobbers:")
-
-		for _, d := range alloc.BlobberDetails {
-			fmt.Println("    - blobber_id:      ", d.BlobberID)
-			fmt.Println("      base URL:        ", getBaseURL(d.BlobberID, alloc.Blobbers))
-			fmt.Println("      size:            ", common.Size(d.Size))
-			fmt.Println("      min_lock_demand: ", common.Balance(d.MinLockDemand))
-			fmt.Println("      spent:           ", common.Balance(d.Spent), "(moved to challenge pool or to the blobber)")
-			fmt.Println("      penalty:         ", common.Balance(d.Penalty), "(blobber stake slash)")
-			fmt.Println("      read_reward:     ", common.Balance(d.ReadReward))
-			fmt.Println("      returned:        ", common.Balance(d.Returned), "(on challenge failed)")
-			fmt.Println("      challenge_reward:", common.Balance(d.ChallengeReward), "(on challenge passed)")
-			fmt.Println("      final_reward:    ", common.Balance(d.FinalReward), "(if finalized)")
-			fmt.Println("      terms: (allocation related terms)")
-			fmt.Println("        read_price:               ", d.Terms.ReadPrice, "/ GB (by 64KB chunks)")
-			fmt.Println("        write_price:              ", d.Terms.WritePrice, "/ GB")
-			fmt.Println("        min_lock_demand:          ", d.Terms.MinLockDemand*100, "%")
-			fmt.Println("        max_offer_duration:       ", d.Terms.MaxOfferDuration)
-			fmt.Println("        challenge_completion_time:", d.Terms.ChallengeCompletionTime)
-		}
+	fmt.Println("allocation:")
+	fmt.Println("  id:             ", alloc.ID)
+	fmt.Println("  tx:             ", alloc.Tx, "(latest create/update allocation transaction hash)")
+	fmt.Println("  data_shards:    ", alloc.DataShards)
+	fmt.Println("  parity_shards:  ", alloc.ParityShards)
+	fmt.Println("  size:           ", common.Size(alloc.Size))
+	fmt.Println("  expiration_date:", common.Timestamp(alloc.Expiration).ToTime())
+	fmt.Println("  immutable:      ", alloc.IsImmutable)
+	fmt.Println("  blobbers:")
 
-		if len(alloc.Curators) < 1 {
-			fmt.Println("  no curators")
-		} else if len(alloc.Curators) == 1 {
-			fmt.Println("  curator: " + alloc.Curators[0])
-		} else {
-			fmt.Println("  curators:")
-			for _, curator := range alloc.Curators {
-				fmt.Println("  ", curator)
-			}
+	for _, d := range alloc.BlobberDetails {
+		fmt.Println("    - blobber_id:      ", d.BlobberID)
+		fmt.Println("      base URL:        ", getBaseURL(d.BlobberID, alloc.Blobbers))
+		fmt.Println("      size:            ", common.Size(d.Size))
+		fmt.Println("      min_lock_demand: ", common.Balance(d.MinLockDemand))
+		fmt.Println("      spent:           ", common.Balance(d.Spent), "(moved to challenge pool or to the blobber)")
+		fmt.Println("      penalty:         ", common.Balance(d.Penalty), "(blobber stake slash)")
+		fmt.Println("      read_reward:     ", common.Balance(d.ReadReward))
+		fmt.Println("      returned:        ", common.Balance(d.Returned), "(on challenge failed)")
+		fmt.Println("      challenge_reward:", common.Balance(d.ChallengeReward), "(on challenge passed)")
+		fmt.Println("      final_reward:    ", common.Balance(d.FinalReward), "(if finalized)")
+		fmt.Println("      terms: (allocation related terms)")
+		fmt.Println("        read_price:               ", d.Terms.ReadPrice, "/ GB (by 64KB chunks)")
+		fmt.Println("        write_price:              ", d.Terms.WritePrice, "/ GB")
+		fmt.Println("        min_lock_demand:          ", d.Terms.MinLockDemand*100, "%")
+		fmt.Println("        max_offer_duration:       ", d.Terms.MaxOfferDuration)
+		fmt.Println("        challenge_completion_time:", d.Terms.ChallengeCompletionTime)
+	}
+
+	if len(alloc.Curators) < 1 {
+		fmt.Println("  no curators")
+	} else if len(alloc.Curators) == 1 {
+		fmt.Println("  curator: " + alloc.Curators[0])
+	} else {
+		fmt.Println("  curators:")
+		for _, curator := range alloc.Curators {
+			fmt.Println("  ", curator)
 		}
+	}
 
-		fmt.Println("  read_price_range:         ", priceRangeString(alloc.ReadPriceRange), "(requested)")
-		fmt.Println("  write_price_range:        ", priceRangeString(alloc.WritePriceRange), "(requested)")
-		fmt.Println("  challenge_completion_time:", alloc.ChallengeCompletionTime, "(max)")
-		fmt.Println("  start_time:               ", common.Timestamp(alloc.StartTime).ToTime())
-		fmt.Println("  finalized:                ", alloc.Finalized)
-		fmt.Println("  canceled:                 ", alloc.Canceled)
-		fmt.Println("  moved_to_challenge:       ", common.Balance(alloc.MovedToChallenge))
-		fmt.Println("  moved_back:               ", common.Balance(alloc.MovedBack))
-		fmt.Println("  moved_to_validators:      ", common.Balance(alloc.MovedToValidators))
-
-		fmt.Println("  stats:")
-		fmt.Println("    total size:             ", common.Size(alloc.Size))
-		fmt.Println("    used size:              ", common.Size(alloc.Stats.UsedSize))
-		fmt.Println("    number of writes:       ", alloc.Stats.NumWrites)
-		fmt.Println("    total challenges:       ", alloc.Stats.TotalChallenges)
-		fmt.Println("    passed challenges:      ", alloc.Stats.SuccessChallenges)
-		fmt.Println("    failed challenges:      ", alloc.Stats.FailedChallenges)
-		fmt.Println("    open challenges:        ", alloc.Stats.OpenChallenges)
-		fmt.Println("    last challenge redeemed:", alloc.Stats.LastestClosedChallengeTxn)
-
-		fmt.Println("  price:")
-		fmt.Println("    time_unit:  ", alloc.TimeUnit)
-		fmt.Println("    read_price: ", downloadCostFor1GB(alloc), "/ GB (by 64KB)")
-		fmt.Println("    write_price:", uploadCostFor1GB(alloc),
-			fmt.Sprintf("/ GB / %s", alloc.TimeUnit))
-		return
-	},
+	fmt.Println("  read_price_range:         ", priceRangeString(alloc.ReadPriceRange), "(requested)")
+	fmt.Println("  write_price_range:        ", priceRangeString(alloc.WritePriceRange), "(requested)")
+	fmt.Println("  challenge_completion_time:", alloc.ChallengeCompletionTime, "(max)")
+	fmt.Println("  start_time:               ", common.Timestamp(alloc.StartTime).ToTime())
+	fmt.Println("  finalized:                ", alloc.Finalized)
+	fmt.Println("  canceled:                 ", alloc.Canceled)
+	fmt.Println("  moved_to_challenge:       ", common.Balance(alloc.MovedToChallenge))
+	fmt.Println("  moved_back:               ", common.Balance(alloc.MovedBack))
+	fmt.Println("  moved_to_validators:      ", common.Balance(alloc.MovedToValidators))
+
+	fmt.Println("  stats:")
+	fmt.Println("    total size:             ", common.Size(alloc.Size))
+	fmt.Println("    used size:              ", common.Size(alloc.Stats.UsedSize))
+	fmt.Println("    number of writes:       ", alloc.Stats.NumWrites)
+	fmt.Println("    total challenges:       ", alloc.Stats.TotalChallenges)
+	fmt.Println("    passed challenges:      ", alloc.Stats.SuccessChallenges)
+	fmt.Println("    failed challenges:      ", alloc.Stats.FailedChallenges)
+	fmt.Println("    open challenges:        ", alloc.Stats.OpenChallenges)
+	fmt.Println("    last challenge redeemed:", alloc.Stats.LastestClosedChallengeTxn)
+
+	fmt.Println("  price:")
+	fmt.Println("    time_unit:  ", alloc.TimeUnit)
+	fmt.Println("    read_price: ", downloadCostFor1GB(alloc), "/ GB (by 64KB)")
+	fmt.Println("    write_price:", uploadCostFor1GB(alloc),
+		fmt.Sprintf("/ GB / %s", alloc.TimeUnit))
 }
 
 func maxInt64(a, b int64) int64 {
@@ -184,36 +196,18 @@ func sizeInGB(size int64) float64 {
 	return float64(size) / GB
 }
 
-func downloadCost(alloc *sdk.Allocation, meta *sdk.ConsolidatedFileMeta) {
+func downloadCost(alloc *sdk.Allocation, meta *sdk.ConsolidatedFileMeta) error {
 
 	if meta.Type != fileref.FILE {
-		log.Fatal("not a file")
+		return commandError("not a file")
 	}
 
-	var (
-		ps  = perShard(meta.Size, alloc.DataShards, alloc.ParityShards)
-		cps = (ps + fileref.CHUNK_SIZE - 1) / fileref.CHUNK_SIZE
-
-		size float64        // GB
-		cost common.Balance //
-	)
-
-	// the Go SDK requests block by 10
-	if cps%10 > 0 {
-		cps = ((cps/10)*10 + 10)
-	}
-
-	size = sizeInGB(cps * fileref.CHUNK_SIZE)
-
-	for _, d := range alloc.BlobberDetails {
-		cost += common.Balance(float64(d.Terms.ReadPrice) * size)
-	}
-
-	cost = cost / common.Balance(len(alloc.BlobberDetails))
+	cost, cps := downloadCostForSize(alloc, meta.Size)
 
 	fmt.Printf("%s tokens for %d 64KB blocks (%s) of %s", cost, cps,
 		common.Size(meta.Size), meta.Path)
 	fmt.Println()
+	return nil
 }
 
 // The getDownloadCostCmd returns value in tokens to download a file.
@@ -222,7 +216,7 @@ var getDownloadCostCmd = &cobra.Command{
 	Short: "Get downloading cost",
 	Long:  `Get downloading cost`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			fflags  = cmd.Flags()
@@ -231,7 +225,7 @@ var getDownloadCostCmd = &cobra.Command{
 		)
 
 		if !fflags.Changed("allocation") {
-			log.Fatal("missing required 'allocation' flag")
+			return usageError("missing required 'allocation' flag")
 		}
 
 		allocID = cmd.Flag("allocation").Value.String()
@@ -244,24 +238,24 @@ var getDownloadCostCmd = &cobra.Command{
 
 		if fflags.Changed("remotepath") {
 			if remotePath, err = fflags.GetString("remotepath"); err != nil {
-				log.Fatal("invalid 'remotepath' flag: ", err)
+				return usageError("invalid 'remotepath' flag: ", err)
 			}
 		}
 
 		if fflags.Changed("authticket") {
 			if authTicket, err = fflags.GetString("authticket"); err != nil {
-				log.Fatal("invalid 'authticket' flag: ", err)
+				return usageError("invalid 'authticket' flag: ", err)
 			}
 		}
 
 		if fflags.Changed("lookuphash") {
 			if lookupHash, err = fflags.GetString("lookuphash"); err != nil {
-				log.Fatal("invalid 'lookuphash' flag: ", err)
+				return usageError("invalid 'lookuphash' flag: ", err)
 			}
 		}
 
 		if remotePath == "" && authTicket == "" {
-			log.Fatal("'remotepath' or 'authticket' flag required")
+			return usageError("'remotepath' or 'authticket' flag required")
 		}
 
 		var (
@@ -274,37 +268,36 @@ var getDownloadCostCmd = &cobra.Command{
 			// by remote path
 
 			if alloc, err = sdk.GetAllocation(allocID); err != nil {
-				log.Fatal("fetching the allocation: ", err)
+				return commandError("fetching the allocation: ", err)
 			}
 
 			if meta, err = alloc.GetFileMeta(remotePath); err != nil {
-				log.Fatal("can't get file meta: ", err)
+				return commandError("can't get file meta: ", err)
 			}
 
-			downloadCost(alloc, meta)
-			return
+			return downloadCost(alloc, meta)
 		}
 
 		// by authentication ticket
 
 		alloc, err = sdk.GetAllocationFromAuthTicket(authTicket)
 		if err != nil {
-			log.Fatal("can't get allocation object: ", err)
+			return commandError("can't get allocation object: ", err)
 		}
 		var at = sdk.InitAuthTicket(authTicket)
 
 		if lookupHash == "" {
 			if lookupHash, err = at.GetLookupHash(); err != nil {
-				log.Fatal("can't get lookup hash from auth ticket: ", err)
+				return commandError("can't get lookup hash from auth ticket: ", err)
 			}
 		}
 
 		meta, err = alloc.GetFileMetaFromAuthTicket(authTicket, lookupHash)
 		if err != nil {
-			log.Fatal("can't get file meta: ", err)
+			return commandError("can't get file meta: ", err)
 		}
 
-		downloadCost(alloc, meta)
+		return downloadCost(alloc, meta)
 	},
 }
 
@@ -313,12 +306,7 @@ var getDownloadCostCmd = &cobra.Command{
 func uploadCost(alloc *sdk.Allocation, size int64, path string,
 	duration time.Duration) {
 
-	var cost common.Balance // total price for size / duration
-
-	for _, d := range alloc.BlobberDetails {
-		cost += uploadCostForBlobber(float64(d.Terms.WritePrice), size,
-			alloc.DataShards, alloc.ParityShards)
-	}
+	var cost = uploadCostForSize(alloc, size) // total price for size / duration
 
 	switch {
 	case duration == 0:
@@ -343,7 +331,7 @@ var getUploadCostCmd = &cobra.Command{
 	Short: "Get uploading cost",
 	Long:  `Get uploading cost`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			fflags   = cmd.Flags()
@@ -354,7 +342,7 @@ var getUploadCostCmd = &cobra.Command{
 		)
 
 		if !fflags.Changed("allocation") {
-			log.Fatal("missing required 'allocation' flag")
+			return usageError("missing required 'allocation' flag")
 		}
 
 		allocID = cmd.Flag("allocation").Value.String()
@@ -362,39 +350,39 @@ var getUploadCostCmd = &cobra.Command{
 		var localPath string
 
 		if !fflags.Changed("localpath") {
-			log.Fatal("missing requried 'localpath' flag")
+			return usageError("missing requried 'localpath' flag")
 		}
 
 		if localPath, err = fflags.GetString("localpath"); err != nil {
-			log.Fatal("invalid 'localpath' flag: ", err)
+			return usageError("invalid 'localpath' flag: ", err)
 		}
 
 		if localPath == "" {
-			log.Fatal("empty local path")
+			return commandError("empty local path")
 		}
 
 		var fi os.FileInfo
 		if fi, err = os.Stat(localPath); err != nil {
-			log.Fatal(err)
+			return commandError(err)
 		}
 
 		if !fi.Mode().IsRegular() {
-			log.Fatal("not a regular file")
+			return commandError("not a regular file")
 		}
 
 		if duration, err = fflags.GetDuration("duration"); err != nil {
-			log.Fatal("invalid 'duration' flag:", err)
+			return usageError("invalid 'duration' flag:", err)
 		} else if duration < 0 {
-			log.Fatal("negative duration not allowed: ", duration)
+			return usageError("negative duration not allowed: ", duration)
 		}
 
 		if end, err = fflags.GetBool("end"); err != nil {
-			log.Fatal("invalid 'end' flag:", err)
+			return usageError("invalid 'end' flag:", err)
 		}
 
 		var alloc *sdk.Allocation
 		if alloc, err = sdk.GetAllocation(allocID); err != nil {
-			log.Fatal("fetching the allocation: ", err)
+			return commandError("fetching the allocation: ", err)
 		}
 
 		// until allocation ends
@@ -404,6 +392,7 @@ var getUploadCostCmd = &cobra.Command{
 		}
 
 		uploadCost(alloc, fi.Size(), localPath, duration)
+		return nil
 	},
 }
 
@@ -414,7 +403,7 @@ func init() {
 
 	getallocationCmd.PersistentFlags().String("allocation", "", "Allocation ID")
 	getallocationCmd.MarkFlagRequired("allocation")
-	getallocationCmd.Flags().Bool("json", false, "pass this option to print response as json data")
+	getallocationCmd.Flags().Bool("json", false, "pass this option to print response as json data, same as --output json")
 
 	dcpf := getDownloadCostCmd.PersistentFlags()
 	dcpf.String("allocation", "", "allocation ID, required")
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/0chain/gosdk/zboxcore/sdk"
@@ -16,25 +15,25 @@ var getMptKeyCommand = &cobra.Command{
 	Short: "Directly view blockchain data",
 	Long:  `Directly view blockchain data from MPT key`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if cmd.Flags().Changed("key") == false {
-			log.Fatal("Required Mpt key missing\n")
+			return usageError("Required Mpt key missing\n")
 		}
 		key := cmd.Flag("key").Value.String()
 		jsonBytes, err := sdk.GetMptData(key)
 		if err != nil {
-			log.Fatalf("Failed to get Mpt key: %v\n", err)
+			return commandError("Failed to get Mpt key:", err)
 		}
 
 		var indented bytes.Buffer
 		err = json.Indent(&indented, jsonBytes, "", "\t")
 		if err != nil {
-			log.Fatalf("Result %s baddly formated: %v\n", string(jsonBytes), err)
+			return commandError(fmt.Sprintf("Result %s baddly formated: %v\n", string(jsonBytes), err))
 		}
 
 		noBackSlash := strings.Replace(indented.String(), "\\", "", -1)
 		fmt.Println(key, ": ", noBackSlash)
-		return
+		return nil
 	},
 }
 
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/0chain/gosdk/zboxcore/fileref"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/spf13/cobra"
+)
+
+// ignoreFileName is the file of patterns of paths sync, get-diff and recursive upload and download skip,
+// read in each directory of the local tree as git reads .gitignore
+const ignoreFileName = ".zboxignore"
+
+// defaultIgnores are always skipped, as they were by the filter of sync
+var defaultIgnores = []string{".DS_Store", ".git"}
+
+// ignorePattern is a pattern of a .zboxignore file or of an --include or --exclude flag
+type ignorePattern struct {
+	base     string   // directory of the .zboxignore file, relative to the root, empty for the root
+	segments []string // pattern split by /
+	negate   bool     // pattern started with !, it includes paths again
+	dirOnly  bool     // pattern ended with /, it only matches directories
+	anchored bool     // pattern has a / before its end, it matches paths relative to base instead of names
+}
+
+// parseIgnorePattern parses a line of a .zboxignore file in directory base, it returns false for blank lines and comments
+func parseIgnorePattern(line, base string) (ignorePattern, bool) {
+	p := ignorePattern{base: base}
+	line = strings.TrimRight(line, " \t\r")
+	if len(line) == 0 || strings.HasPrefix(line, "#") {
+		return p, false
+	}
+	if strings.HasPrefix(line, "!") {
+		p.negate, line = true, line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		p.anchored, line = true, strings.TrimLeft(line, "/")
+	}
+	if len(line) == 0 {
+		return p, false
+	}
+	p.segments = strings.Split(line, "/")
+	return p, true
+}
+
+// match tells if the pattern matches rel, a path relative to the root
+func (p ignorePattern) match(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if len(p.base) > 0 {
+		if !strings.HasPrefix(rel, p.base+"/") {
+			return false
+		}
+		rel = rel[len(p.base)+1:]
+	}
+	if !p.anchored {
+		return matchSegments(p.segments, []string{path.Base(rel)})
+	}
+	return matchSegments(p.segments, strings.Split(rel, "/"))
+}
+
+// matchSegments matches path segments to glob segments, where ** matches any number of segments,
+// except a trailing one which matches one or more, so foo/** matches what is in foo but not foo itself
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" && len(pattern) == 1 {
+			return len(name) > 0
+		}
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// pathFilter tells which paths of a tree sync, get-diff and recursive upload and download skip:
+// those ignored by the .zboxignore files of the local tree or by --exclude, and files not matching --include if it is set
+type pathFilter struct {
+	root     string                     // local root the .zboxignore files are read in
+	ignores  map[string][]ignorePattern // patterns of the .zboxignore file of each directory read so far
+	excludes []ignorePattern
+	includes []ignorePattern
+}
+
+// newPathFilter returns the filter of the --include and --exclude flags of cmd and of the .zboxignore files in root
+func newPathFilter(cmd *cobra.Command, root string) (*pathFilter, error) {
+	f := &pathFilter{root: root, ignores: make(map[string][]ignorePattern)}
+	excludes, _ := cmd.Flags().GetStringArray("exclude")
+	includes, _ := cmd.Flags().GetStringArray("include")
+	for _, list := range []struct {
+		globs    []string
+		patterns *[]ignorePattern
+	}{{append(append([]string{}, defaultIgnores...), excludes...), &f.excludes}, {includes, &f.includes}} {
+		for _, glob := range list.globs {
+			p, ok := parseIgnorePattern(glob, "")
+			if !ok || p.negate {
+				return nil, usageError("Error: invalid glob " + glob)
+			}
+			for _, s := range p.segments {
+				if _, err := path.Match(s, ""); err != nil {
+					return nil, usageError("Error: invalid glob "+glob+":", err)
+				}
+			}
+			*list.patterns = append(*list.patterns, p)
+		}
+	}
+	return f, nil
+}
+
+// ignoreFile returns the patterns of the .zboxignore file of dir, relative to the root, reading it once
+func (f *pathFilter) ignoreFile(dir string) []ignorePattern {
+	if patterns, ok := f.ignores[dir]; ok {
+		return patterns
+	}
+	var patterns []ignorePattern
+	if file, err := os.Open(filepath.Join(f.root, filepath.FromSlash(dir), ignoreFileName)); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if p, ok := parseIgnorePattern(scanner.Text(), dir); ok {
+				patterns = append(patterns, p)
+			}
+		}
+		file.Close()
+	}
+	f.ignores[dir] = patterns
+	return patterns
+}
+
+// ignored tells if rel is ignored by the .zboxignore files of its parent directories or by --exclude,
+// the last matching pattern of the deepest file wins as in .gitignore
+func (f *pathFilter) ignored(rel string, isDir bool) bool {
+	for _, p := range f.excludes {
+		if p.match(rel, isDir) {
+			return true
+		}
+	}
+	dirs := []string{""}
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		dirs = append(dirs, strings.Join(parts[:i], "/"))
+	}
+	var ignored bool
+	for _, dir := range dirs {
+		for _, p := range f.ignoreFile(dir) {
+			if p.match(rel, isDir) {
+				ignored = !p.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// Skip tells if the file or directory at rel, a path relative to the root, is skipped.
+// A path is skipped if a parent directory is, so a directory can't be included again by a pattern of a file in it.
+func (f *pathFilter) Skip(rel string, isDir bool) bool {
+	rel = strings.Trim(filepath.ToSlash(rel), "/")
+	if len(rel) == 0 || rel == "." {
+		return false
+	}
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' && f.ignored(rel[:i], true) {
+			return true
+		}
+	}
+	if f.ignored(rel, isDir) {
+		return true
+	}
+	if isDir || len(f.includes) == 0 {
+		return false
+	}
+	for _, p := range f.includes {
+		for sub := rel; ; sub = path.Dir(sub) {
+			if p.match(sub, sub != rel) {
+				return false
+			}
+			if !strings.Contains(sub, "/") {
+				break
+			}
+		}
+	}
+	return true
+}
+
+// filterDiff removes the files of lDiff the filter skips, on either side, and returns the paths to exclude
+// from the local cache, as filterOperations does: the topmost skipped directory of a file, or else the file,
+// so the cache keeps no entry of a skipped directory either
+func (f *pathFilter) filterDiff(lDiff []sdk.FileDiff) (filterDiff []sdk.FileDiff, exclPath []string) {
+	excluded := make(map[string]bool)
+	for _, d := range lDiff {
+		if !f.Skip(d.Path, d.Type == fileref.DIRECTORY) {
+			filterDiff = append(filterDiff, d)
+			continue
+		}
+		excl := d.Path
+		for i := 1; i < len(d.Path); i++ {
+			if d.Path[i] == '/' && f.Skip(d.Path[:i], true) {
+				excl = d.Path[:i]
+				break
+			}
+		}
+		if !excluded[excl] {
+			excluded[excl] = true
+			exclPath = append(exclPath, excl)
+		}
+	}
+	return
+}
+
+// addFilterFlags adds the --include and --exclude flags of the path filter to cmd
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("include", []string{}, "only these files, by glob of their name or path relative to the root, can be repeated")
+	cmd.Flags().StringArray("exclude", []string{}, "skip these files and directories, by glob of their name or path relative to the root, can be repeated")
+}
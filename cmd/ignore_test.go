@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestParseIgnorePattern(t *testing.T) {
+	tests := []struct {
+		line string
+		base string
+		want ignorePattern
+		ok   bool
+	}{
+		{"", "", ignorePattern{}, false},
+		{"# comment", "", ignorePattern{}, false},
+		{"*.log", "", ignorePattern{segments: []string{"*.log"}}, true},
+		{"*.log  ", "sub", ignorePattern{base: "sub", segments: []string{"*.log"}}, true},
+		{"build/", "", ignorePattern{segments: []string{"build"}, dirOnly: true}, true},
+		{"/build", "", ignorePattern{segments: []string{"build"}, anchored: true}, true},
+		{"docs/*.md", "", ignorePattern{segments: []string{"docs", "*.md"}, anchored: true}, true},
+		{"**/tmp", "", ignorePattern{segments: []string{"**", "tmp"}, anchored: true}, true},
+		{"!keep.log", "", ignorePattern{segments: []string{"keep.log"}, negate: true}, true},
+		{`\!important`, "", ignorePattern{segments: []string{"!important"}}, true},
+		{`\#hash`, "", ignorePattern{segments: []string{"#hash"}}, true},
+		{"/", "", ignorePattern{dirOnly: true}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseIgnorePattern(tt.line, tt.base)
+		if ok != tt.ok || (ok && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("parseIgnorePattern(%q, %q) = %+v, %v, want %+v, %v", tt.line, tt.base, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestIgnorePatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		base    string
+		rel     string
+		isDir   bool
+		want    bool
+	}{
+		// unanchored patterns match names at any depth
+		{"*.log", "", "a.log", false, true},
+		{"*.log", "", "x/y/a.log", false, true},
+		{"*.log", "", "a.txt", false, false},
+		// anchored patterns match paths relative to the directory of their file
+		{"/build", "", "build", true, true},
+		{"/build", "", "x/build", true, false},
+		{"docs/*.md", "", "docs/a.md", false, true},
+		{"docs/*.md", "", "x/docs/a.md", false, false},
+		{"docs/*.md", "", "docs/x/a.md", false, false},
+		{"*.tmp", "sub", "sub/a.tmp", false, true},
+		{"*.tmp", "sub", "a.tmp", false, false},
+		{"/out", "sub", "sub/out", true, true},
+		{"/out", "sub", "out", true, false},
+		// dir/ only matches directories
+		{"cache/", "", "cache", true, true},
+		{"cache/", "", "cache", false, false},
+		{"cache/", "", "x/cache", true, true},
+		// ** matches any number of directories, a trailing one at least one
+		{"**/tmp", "", "tmp", true, true},
+		{"**/tmp", "", "x/y/tmp", true, true},
+		{"a/**/b", "", "a/b", false, true},
+		{"a/**/b", "", "a/x/y/b", false, true},
+		{"a/**/b", "", "a/x/c", false, false},
+		{"foo/**", "", "foo", true, false},
+		{"foo/**", "", "foo/a", false, true},
+		{"foo/**", "", "foo/x/a", false, true},
+		{"foo/**", "", "bar/a", false, false},
+	}
+	for _, tt := range tests {
+		p, ok := parseIgnorePattern(tt.pattern, tt.base)
+		if !ok {
+			t.Fatalf("parseIgnorePattern(%q) failed", tt.pattern)
+		}
+		if got := p.match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("%q in %q matches %s (dir %v): %v, want %v", tt.pattern, tt.base, tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestPathFilterSkip(t *testing.T) {
+	root := t.TempDir()
+	ignoreFiles := map[string]string{
+		ignoreFileName:                          "*.log\n!keep.log\nbuild/\n/secret\nfoo/**\n",
+		filepath.Join("sub", ignoreFileName):    "*.tmp\n!*.log\n",
+		filepath.Join("build", ignoreFileName):  "!*.bin\n",
+		filepath.Join("secret", ignoreFileName): "!*\n",
+	}
+	for name, content := range ignoreFiles {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		excludes []string
+		includes []string
+		rel      string
+		isDir    bool
+		want     bool
+	}{
+		{"root", nil, nil, "", true, false},
+		{"unignored file", nil, nil, "a.txt", false, false},
+		{"default ignore", nil, nil, ".git", true, true},
+		{"file in a default ignore", nil, nil, ".git/config", false, true},
+		{"unanchored pattern", nil, nil, "x/a.log", false, true},
+		{"negation", nil, nil, "keep.log", false, false},
+		{"negation in a deeper file wins", nil, nil, "sub/a.log", false, false},
+		{"pattern of a deeper file", nil, nil, "sub/a.tmp", false, true},
+		{"pattern of a deeper file only applies under it", nil, nil, "a.tmp", false, false},
+		{"dir/ pattern", nil, nil, "build", true, true},
+		{"dir/ pattern does not match files", nil, nil, "x/build", false, false},
+		{"file in an ignored directory", nil, nil, "build/a.txt", false, true},
+		{"parent exclusion wins over a negation in it", nil, nil, "build/a.bin", false, true},
+		{"anchored pattern", nil, nil, "secret", true, true},
+		{"anchored pattern only matches at the root", nil, nil, "x/secret", true, false},
+		{"file in an anchored directory", nil, nil, "secret/a.txt", false, true},
+		{"trailing ** does not match the directory", nil, nil, "foo", true, false},
+		{"trailing ** matches what is in it", nil, nil, "foo/a.txt", false, true},
+		{"--exclude name", []string{"*.txt"}, nil, "x/a.txt", false, true},
+		{"--exclude directory", []string{"docs"}, nil, "docs/a.md", false, true},
+		{"--exclude path", []string{"x/*.md"}, nil, "x/a.md", false, true},
+		{"--exclude path does not match deeper", []string{"x/*.md"}, nil, "y/x/a.md", false, false},
+		{"--include matching file", nil, []string{"*.md"}, "docs/a.md", false, false},
+		{"--include other file", nil, []string{"*.md"}, "docs/a.txt", false, true},
+		{"--include keeps directories", nil, []string{"*.md"}, "docs", true, false},
+		{"--include directory keeps what is in it", nil, []string{"docs"}, "docs/x/a.txt", false, false},
+		{"--include path", nil, []string{"docs/*.md"}, "docs/a.md", false, false},
+		{"--include does not include ignored files", nil, []string{"*.log"}, "x/a.log", false, true},
+		{"--exclude wins over --include", []string{"a.md"}, []string{"*.md"}, "a.md", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addFilterFlags(cmd)
+			for _, e := range tt.excludes {
+				cmd.Flags().Set("exclude", e)
+			}
+			for _, i := range tt.includes {
+				cmd.Flags().Set("include", i)
+			}
+			f, err := newPathFilter(cmd, root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Skip(tt.rel, tt.isDir); got != tt.want {
+				t.Errorf("Skip(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathFilterInvalidGlob(t *testing.T) {
+	for _, glob := range []string{"[a-", "!negated", "/"} {
+		cmd := &cobra.Command{}
+		addFilterFlags(cmd)
+		cmd.Flags().Set("exclude", glob)
+		if _, err := newPathFilter(cmd, t.TempDir()); err == nil {
+			t.Errorf("--exclude %q is accepted", glob)
+		}
+	}
+}
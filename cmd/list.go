@@ -17,42 +17,32 @@ var listCmd = &cobra.Command{
 	Short: "list files from blobbers",
 	Long:  `list files from blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags() // fflags is a *flag.FlagSet
 		if fflags.Changed("remotepath") == false && fflags.Changed("authticket") == false {
-			PrintError("Error: remotepath / authticket flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath / authticket flag is missing")
 		}
 
 		remotepath := cmd.Flag("remotepath").Value.String()
 		authticket := cmd.Flag("authticket").Value.String()
 		lookuphash := cmd.Flag("lookuphash").Value.String()
-		doJSON, _ := cmd.Flags().GetBool("json")
 		if len(remotepath) == 0 && (len(authticket) == 0) {
-			PrintError("Error: remotepath / authticket / lookuphash flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath / authticket / lookuphash flag is missing")
 		}
 
 		if len(remotepath) > 0 {
 			if fflags.Changed("allocation") == false { // check if the flag "path" is set
-				PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-				os.Exit(1)                                      // and os.Exit(1)
+				return usageError("Error: allocation flag is missing")
 			}
 			allocationID := cmd.Flag("allocation").Value.String()
 			allocationObj, err := sdk.GetAllocation(allocationID)
 			if err != nil {
-				PrintError("Error fetching the allocation", err)
-				os.Exit(1)
+				return commandError("Error fetching the allocation", err)
 			}
 			remotepath := cmd.Flag("remotepath").Value.String()
 			ref, err := allocationObj.ListDir(remotepath)
 			if err != nil {
-				PrintError(err.Error())
-				os.Exit(1)
-			}
-			if doJSON {
-				util.PrintJSON(ref.Children)
-				return
+				return commandError(err)
 			}
 			header := []string{"Type", "Name", "Path", "Size", "Num Blocks", "Lookup Hash", "Is Encrypted", "Downloads payer"}
 			data := make([][]string, len(ref.Children))
@@ -80,37 +70,31 @@ var listCmd = &cobra.Command{
 					child.Attributes.WhoPaysForReads.String(),
 				}
 			}
-			util.WriteTable(os.Stdout, header, []string{}, data)
+			printOutput(cmd, ref.Children, listCSVHeader, listCSVData(ref.Children), func() {
+				util.WriteTable(os.Stdout, header, []string{}, data)
+			})
 		} else if len(authticket) > 0 {
 			allocationObj, err := sdk.GetAllocationFromAuthTicket(authticket)
 			if err != nil {
-				PrintError("Error fetching the allocation", err)
-				os.Exit(1)
+				return commandError("Error fetching the allocation", err)
 			}
 			at := sdk.InitAuthTicket(authticket)
 			isDir, err := at.IsDir()
 			if isDir && len(lookuphash) == 0 {
 				lookuphash, err = at.GetLookupHash()
 				if err != nil {
-					PrintError("Error getting the lookuphash from authticket", err)
-					os.Exit(1)
+					return commandError("Error getting the lookuphash from authticket", err)
 				}
 			}
 			if !isDir {
-				PrintError("Invalid operation. Auth ticket is not for a directory")
-				os.Exit(1)
+				return commandError("Invalid operation. Auth ticket is not for a directory")
 			}
 
 			ref, err := allocationObj.ListDirFromAuthTicket(authticket, lookuphash)
 			if err != nil {
-				PrintError(err.Error())
-				os.Exit(1)
+				return commandError(err)
 			}
 
-			if doJSON {
-				util.PrintJSON(ref.Children)
-				return
-			}
 			header := []string{"Type", "Name", "Size", "Num Blocks", "Lookup Hash", "Is Encrypted", "Downloads payer"}
 			data := make([][]string, len(ref.Children))
 			for idx, child := range ref.Children {
@@ -133,35 +117,54 @@ var listCmd = &cobra.Command{
 					child.Attributes.WhoPaysForReads.String(),
 				}
 			}
-			util.WriteTable(os.Stdout, header, []string{}, data)
+			printOutput(cmd, ref.Children, listCSVHeader, listCSVData(ref.Children), func() {
+				util.WriteTable(os.Stdout, header, []string{}, data)
+			})
 		}
 
-		return
+		return nil
 	},
 }
 
+// listCSVHeader is the csv header of list, see listCSVData
+var listCSVHeader = []string{"type", "name", "path", "size", "num_blocks", "lookup_hash", "encrypted", "who_pays_for_reads"}
+
+func listCSVData(children []*sdk.ListResult) [][]string {
+	data := make([][]string, len(children))
+	for idx, child := range children {
+		data[idx] = []string{
+			child.Type,
+			child.Name,
+			child.Path,
+			strconv.FormatInt(child.Size, 10),
+			strconv.FormatInt(child.NumBlocks, 10),
+			child.LookupHash,
+			strconv.FormatBool(len(child.EncryptionKey) > 0),
+			child.Attributes.WhoPaysForReads.String(),
+		}
+	}
+	return data
+}
+
 var listAllCmd = &cobra.Command{
 	Use:   "list-all",
 	Short: "list all files from blobbers",
 	Long:  `list all files from blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags()                      // fflags is a *flag.FlagSet
 		if fflags.Changed("allocation") == false { // check if the flag "path" is set
-			PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-			os.Exit(1)                                      // and return
+			return usageError("Error: allocation flag is missing")
 		}
 
 		allocationID := cmd.Flag("allocation").Value.String()
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation", err)
 		}
 		ref, err := allocationObj.GetRemoteFileMap(nil)
 		if err != nil {
-			PrintError(err.Error())
-			os.Exit(1)
+			return commandError(err)
 		}
 
 		type fileResp struct {
@@ -186,7 +189,7 @@ var listAllCmd = &cobra.Command{
 		}
 
 		util.PrintJSON(fileResps)
-		return
+		return nil
 	},
 }
 
@@ -196,7 +199,7 @@ func init() {
 	listCmd.PersistentFlags().String("remotepath", "", "Remote path to list from")
 	listCmd.PersistentFlags().String("authticket", "", "Auth ticket fot the file to download if you dont own it")
 	listCmd.PersistentFlags().String("lookuphash", "", "The remote lookuphash of the object retrieved from the list")
-	listCmd.Flags().Bool("json", false, "pass this option to print response as json data")
+	listCmd.Flags().Bool("json", false, "pass this option to print response as json data, same as --output json")
 	listCmd.MarkFlagRequired("allocation")
 
 	rootCmd.AddCommand(listAllCmd)
@@ -16,17 +16,10 @@ var listallocationsCmd = &cobra.Command{
 	Use:   "listallocations",
 	Short: "List allocations for the client",
 	Long:  `List allocations for the client`,
-	Run: func(cmd *cobra.Command, args []string) {
-		doJSON, _ := cmd.Flags().GetBool("json")
-
+	RunE: func(cmd *cobra.Command, args []string) error {
 		allocations, err := sdk.GetAllocations()
 		if err != nil {
-			PrintError("Error getting allocations list." + err.Error())
-			os.Exit(1)
-		}
-		if doJSON {
-			util.PrintJSON(allocations)
-			return
+			return commandError("Error getting allocations list.", err)
 		}
 		header := []string{"ID", "Size", "Expiration", "Datashards",
 			"Parityshards", "Finalized", "Canceled", "R. Price", "W. Price"}
@@ -57,12 +50,50 @@ var listallocationsCmd = &cobra.Command{
 				rp.String(), wp.String(),
 			}
 		}
-		util.WriteTable(os.Stdout, header, []string{}, data)
-		return
+		csvData := make([][]string, len(allocations))
+		for idx, allocation := range allocations {
+			csvData[idx] = allocationCSVRow(allocation)
+		}
+		printOutput(cmd, allocations, allocationCSVHeader, csvData, func() {
+			util.WriteTable(os.Stdout, header, []string{}, data)
+		})
+		return nil
 	},
 }
 
+// allocationCSVHeader is the csv header of listallocations and get, see allocationCSVRow
+var allocationCSVHeader = []string{"id", "tx", "owner_id", "size", "expiration_date", "data_shards", "parity_shards",
+	"is_immutable", "finalized", "canceled", "used_size", "num_of_writes", "read_price", "write_price"}
+
+// allocationCSVRow flattens alloc for csv output, read_price and write_price are the sums of blobber terms
+func allocationCSVRow(alloc *sdk.Allocation) []string {
+	var rp, wp common.Balance
+	for _, d := range alloc.BlobberDetails {
+		rp += d.Terms.ReadPrice
+		wp += d.Terms.WritePrice
+	}
+	var usedSize, numWrites int64
+	if alloc.Stats != nil {
+		usedSize = alloc.Stats.UsedSize
+		numWrites = alloc.Stats.NumWrites
+	}
+	return []string{
+		alloc.ID, alloc.Tx, alloc.Owner,
+		strconv.FormatInt(alloc.Size, 10),
+		strconv.FormatInt(alloc.Expiration, 10),
+		strconv.Itoa(alloc.DataShards),
+		strconv.Itoa(alloc.ParityShards),
+		strconv.FormatBool(alloc.IsImmutable),
+		strconv.FormatBool(alloc.Finalized),
+		strconv.FormatBool(alloc.Canceled),
+		strconv.FormatInt(usedSize, 10),
+		strconv.FormatInt(numWrites, 10),
+		strconv.FormatInt(int64(rp), 10),
+		strconv.FormatInt(int64(wp), 10),
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(listallocationsCmd)
-	listallocationsCmd.Flags().Bool("json", false, "pass this option to print response as json data")
+	listallocationsCmd.Flags().Bool("json", false, "pass this option to print response as json data, same as --output json")
 }
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0chain/gosdk/core/logger"
+	"github.com/0chain/gosdk/core/version"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/0chain/gosdk/zcncore"
+	"gopkg.in/yaml.v2"
+)
+
+// log formats of the --log-format flag
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// defaults of the log settings, the log file is in the config directory
+const (
+	defaultLogFile       = "cmdlog.log"
+	defaultLogLevel      = "debug"
+	defaultLogMaxSize    = 10
+	defaultLogMaxBackups = 3
+)
+
+// logLevels are the levels of the sdk loggers, by name
+var logLevels = map[string]int{
+	"none":  logger.NONE,
+	"fatal": logger.FATAL,
+	"error": logger.ERROR,
+	"info":  logger.INFO,
+	"debug": logger.DEBUG,
+}
+
+var logFile string
+var logLevel string
+var logFormat string
+
+// logSettings are the log settings of config.yaml, which the --log-* flags override
+type logSettings struct {
+	File       string `yaml:"log_file"`
+	Level      string `yaml:"log_level"`
+	Format     string `yaml:"log_format"`
+	MaxSize    int64  `yaml:"log_max_size"`
+	MaxBackups int    `yaml:"log_max_backups"`
+}
+
+// validate checks the level and format, and sets the defaults of the settings not given
+func (s *logSettings) validate() error {
+	if len(s.File) == 0 {
+		s.File = defaultLogFile
+	}
+	if len(s.Level) == 0 {
+		s.Level = defaultLogLevel
+	}
+	if len(s.Format) == 0 {
+		s.Format = logFormatText
+	}
+	if s.MaxSize <= 0 {
+		s.MaxSize = defaultLogMaxSize
+	}
+	if s.MaxBackups < 0 {
+		s.MaxBackups = defaultLogMaxBackups
+	}
+	s.Level = strings.ToLower(s.Level)
+	if _, ok := logLevels[s.Level]; !ok {
+		return fmt.Errorf("invalid log level %s, valid levels are none, fatal, error, info and debug", s.Level)
+	}
+	switch s.Format {
+	case logFormatText, logFormatJSON:
+	default:
+		return fmt.Errorf("invalid log format %s, valid formats are text and json", s.Format)
+	}
+	return nil
+}
+
+// validateLogFlags checks the --log-level and --log-format flags before a command runs
+func validateLogFlags() error {
+	s := logSettings{Level: logLevel, Format: logFormat}
+	if err := s.validate(); err != nil {
+		return usageError("Error:", err)
+	}
+	return nil
+}
+
+// loadLogSettings reads the log settings of the config file, overridden by their environment variables and the --log-* flags.
+// The environment variables of log_file, log_level and log_format are those of the flags.
+func loadLogSettings(configFile string) (logSettings, error) {
+	s := logSettings{MaxBackups: -1}
+	buf, err := ioutil.ReadFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return s, err
+	}
+	if err = yaml.Unmarshal(buf, &s); err != nil {
+		return s, err
+	}
+	if v, ok := lookupEnv("log_max_size"); ok {
+		if s.MaxSize, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return s, fmt.Errorf("invalid %s %q", envName("log_max_size"), v)
+		}
+	}
+	if v, ok := lookupEnv("log_max_backups"); ok {
+		if s.MaxBackups, err = strconv.Atoi(v); err != nil {
+			return s, fmt.Errorf("invalid %s %q", envName("log_max_backups"), v)
+		}
+	}
+	if len(logFile) > 0 {
+		s.File = logFile
+	}
+	if len(logLevel) > 0 {
+		s.Level = logLevel
+	}
+	if len(logFormat) > 0 {
+		s.Format = logFormat
+	}
+	return s, s.validate()
+}
+
+// initLogging routes the logs of both sdk loggers to the log file of the config, or of the --log-* flags.
+// A relative log file is in configDir, as a relative wallet file is.
+func initLogging(configDir string) error {
+	s, err := loadLogSettings(getConfigFilePath(configDir))
+	if err != nil {
+		return configError("Invalid log settings:", err)
+	}
+	path := s.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configDir, path)
+	}
+	sink, err := newLogSink(path, s.Format, s.MaxSize<<20, s.MaxBackups)
+	if err != nil {
+		return configError("Error opening the log file:", err)
+	}
+
+	for _, l := range []*logger.Logger{zcncore.GetLogger(), sdk.GetLogger()} {
+		l.SetLevel(logLevels[s.Level])
+		l.SetLogFile(sink, !bSilent)
+	}
+	zcncore.GetLogger().Info("******* Wallet SDK Version:", version.VERSIONSTR, " *******")
+	sdk.GetLogger().Info("******* Storage SDK Version: ", version.VERSIONSTR, " *******")
+	return nil
+}
+
+// logSink writes log entries to a file as text or json, and rotates the file when it would exceed maxSize.
+// Rotated files are renamed with a .1, .2, ... suffix, the oldest beyond maxBackups are removed.
+type logSink struct {
+	mu         sync.Mutex
+	path       string
+	format     string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newLogSink(path, format string, maxSize int64, maxBackups int) (*logSink, error) {
+	s := &logSink{path: path, format: format, maxSize: maxSize, maxBackups: maxBackups}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *logSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file, s.size = f, info.Size()
+	return nil
+}
+
+func (s *logSink) rotate() error {
+	s.file.Close()
+	for i := s.maxBackups; i > 0; i-- {
+		from := s.path
+		if i > 1 {
+			from = fmt.Sprintf("%s.%d", s.path, i-1)
+		}
+		os.Rename(from, fmt.Sprintf("%s.%d", s.path, i))
+	}
+	if s.maxBackups == 0 {
+		os.Remove(s.path)
+	}
+	return s.open()
+}
+
+// Write writes one log entry, as the sdk loggers write each entry at once
+func (s *logSink) Write(p []byte) (int, error) {
+	entry := formatLogEntry(string(p), s.format)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size > 0 && s.size+int64(len(entry)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.file.WriteString(entry)
+	s.size += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+var ansiEscapeRegexp = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// logEntryRegexp matches an entry of the sdk loggers: logger name, [LEVEL], date and time, file:line and message
+var logEntryRegexp = regexp.MustCompile(`(?s)^(\S+)\s+\[(\w+)\]\s+(\d{4}/\d\d/\d\d \d\d:\d\d:\d\d\.\d+) (\S+?): (.*)$`)
+
+// logEntry is an entry of the json log format
+type logEntry struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Logger  string `json:"logger"`
+	Caller  string `json:"caller,omitempty"`
+	Message string `json:"msg"`
+}
+
+// formatLogEntry returns an entry of the sdk loggers without colors, as json if format is json
+func formatLogEntry(entry, format string) string {
+	entry = strings.TrimRight(ansiEscapeRegexp.ReplaceAllString(entry, ""), "\n")
+	if format != logFormatJSON {
+		return entry + "\n"
+	}
+
+	e := logEntry{Time: time.Now().Format(time.RFC3339Nano), Message: entry}
+	if m := logEntryRegexp.FindStringSubmatch(entry); m != nil {
+		e.Logger, e.Level, e.Caller, e.Message = m[1], strings.ToLower(m[2]), m[4], m[5]
+		if t, err := time.ParseInLocation("2006/01/02 15:04:05.000000", m[3], time.Local); err == nil {
+			e.Time = t.Format(time.RFC3339Nano)
+		}
+	}
+	buf, _ := json.Marshal(e)
+	return string(buf) + "\n"
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"sync"
 
 	"github.com/0chain/gosdk/zboxcore/sdk"
@@ -15,26 +14,22 @@ var moveCmd = &cobra.Command{
 	Short: "move an object(file/folder) to another folder on blobbers",
 	Long:  `move an object to another folder on blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags()                      // fflags is a *flag.FlagSet
 		if fflags.Changed("allocation") == false { // check if the flag "path" is set
-			fmt.Println("Error: allocation flag is missing") // If not, we'll let the user know
-			return                                           // and return
+			return usageError("Error: allocation flag is missing")
 		}
 		if fflags.Changed("remotepath") == false {
-			fmt.Println("Error: remotepath flag is missing")
-			return
+			return usageError("Error: remotepath flag is missing")
 		}
 
 		if fflags.Changed("destpath") == false {
-			fmt.Println("Error: destpath flag is missing")
-			return
+			return usageError("Error: destpath flag is missing")
 		}
 		allocationID := cmd.Flag("allocation").Value.String()
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			fmt.Println("Error fetching the allocation", err)
-			return
+			return commandError("Error fetching the allocation", err)
 		}
 		remotepath := cmd.Flag("remotepath").Value.String()
 		destpath := cmd.Flag("destpath").Value.String()
@@ -42,8 +37,7 @@ var moveCmd = &cobra.Command{
 
 		statsMap, err := allocationObj.GetFileStats(remotepath)
 		if err != nil {
-			PrintError("Error in getting information about the object." + err.Error())
-			os.Exit(1)
+			return commandError("Error in getting information about the object.", err)
 		}
 		isFile := false
 		for _, v := range statsMap {
@@ -57,15 +51,13 @@ var moveCmd = &cobra.Command{
 		if isFile && commit {
 			fileMeta, err = allocationObj.GetFileMeta(remotepath)
 			if err != nil {
-				PrintError("Failed to fetch metadata for the given file", err.Error())
-				os.Exit(1)
+				return commandError("Failed to fetch metadata for the given file", err)
 			}
 		}
 
 		err = allocationObj.MoveObject(remotepath, destpath)
 		if err != nil {
-			fmt.Println(err.Error())
-			return
+			return commandError(err)
 		}
 
 		fmt.Println(remotepath + " moved")
@@ -75,13 +67,17 @@ var moveCmd = &cobra.Command{
 				wg := &sync.WaitGroup{}
 				statusBar := &StatusBar{wg: wg}
 				wg.Add(1)
-				commitMetaTxn(remotepath, "Move", "", "", allocationObj, fileMeta, statusBar)
+				if err := commitMetaTxn(remotepath, "Move", "", "", allocationObj, fileMeta, statusBar); err != nil {
+					return err
+				}
 				wg.Wait()
 			} else {
-				commitFolderTxn("Move", remotepath, destpath, allocationObj)
+				if err := commitFolderTxn("Move", remotepath, destpath, allocationObj); err != nil {
+					return err
+				}
 			}
 		}
-		return
+		return nil
 	},
 }
 
@@ -0,0 +1,439 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0chain/gosdk/core/conf"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/0chain/gosdk/zcncore"
+	"github.com/0chain/zboxcli/util"
+	"github.com/spf13/cobra"
+)
+
+// types of the nodes network status probes
+const (
+	nodeBlockWorker = "block_worker"
+	nodeMiner       = "miner"
+	nodeSharder     = "sharder"
+	nodeBlobber     = "blobber"
+)
+
+// nodeStatus is what network status reports of a node. BuildTag is the build of the software of a miner
+// or sharder, as registered with the miner smart contract. BlockVersion is the version of the format
+// of the latest finalized block of a sharder, not the version of its software.
+type nodeStatus struct {
+	Type         string `json:"type"`
+	URL          string `json:"url"`
+	ID           string `json:"id,omitempty"`
+	Up           bool   `json:"up"`
+	LatencyMS    int64  `json:"latency_ms"`
+	Height       int64  `json:"height,omitempty"`
+	BuildTag     string `json:"build_tag,omitempty"`
+	BlockVersion string `json:"block_version,omitempty"`
+	Note         string `json:"note,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// allocationHealth is what network status reports of the blobbers of an allocation
+type allocationHealth struct {
+	ID           string `json:"id"`
+	DataShards   int    `json:"data_shards"`
+	ParityShards int    `json:"parity_shards"`
+	BlobbersUp   int    `json:"blobbers_up"`
+	Blobbers     int    `json:"blobbers"`
+	Readable     bool   `json:"readable"`
+}
+
+// networkStatus is the report of network status
+type networkStatus struct {
+	Nodes      []*nodeStatus     `json:"nodes"`
+	Allocation *allocationHealth `json:"allocation,omitempty"`
+	OK         bool              `json:"ok"`
+}
+
+// getBody gets url and returns its response, and the time the request took.
+// It fails unless the response has a 2xx status.
+func getBody(ctx context.Context, client *http.Client, url string) ([]byte, time.Duration, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	start := time.Now()
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	latency := time.Since(start)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil, latency, fmt.Errorf("%s responded %s", url, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, latency, fmt.Errorf("invalid response of %s: %v", url, err)
+	}
+	return body, latency, nil
+}
+
+// getJSON gets url and decodes its json response into result, if result is not nil, returning the time the request took.
+// It fails unless the response has a 2xx status.
+func getJSON(ctx context.Context, client *http.Client, url string, result interface{}) (time.Duration, error) {
+	body, latency, err := getBody(ctx, client, url)
+	if err != nil || result == nil {
+		return latency, err
+	}
+	if err = json.Unmarshal(body, result); err != nil {
+		return latency, fmt.Errorf("invalid response of %s: %v", url, err)
+	}
+	return latency, nil
+}
+
+// latestBlock is what a sharder responds of its latest finalized block
+type latestBlock struct {
+	Round   int64  `json:"round"`
+	Version string `json:"version"`
+	ChainID string `json:"chain_id"`
+}
+
+// probeBlockWorker gets the miners and sharders of the network of a block worker
+func probeBlockWorker(ctx context.Context, client *http.Client, url string) (conf.Network, time.Duration, error) {
+	var network conf.Network
+	latency, err := getJSON(ctx, client, strings.TrimRight(url, "/")+"/network", &network)
+	return network, latency, err
+}
+
+// probeWhoami gets the id of a miner or sharder. It responds its type, host, port, id
+// and public key, separated by commas.
+func probeWhoami(ctx context.Context, client *http.Client, url string) (string, time.Duration, error) {
+	body, latency, err := getBody(ctx, client, strings.TrimRight(url, "/")+"/_nh/whoami")
+	if err != nil {
+		return "", latency, err
+	}
+	fields := strings.Split(string(body), ",")
+	if len(fields) < 4 {
+		return "", latency, nil
+	}
+	return strings.TrimSpace(fields[3]), latency, nil
+}
+
+// probeSharder gets the latest finalized block of a sharder
+func probeSharder(ctx context.Context, client *http.Client, url string) (*latestBlock, time.Duration, error) {
+	block := &latestBlock{}
+	latency, err := getJSON(ctx, client, strings.TrimRight(url, "/")+"/v1/block/get/latest_finalized", block)
+	return block, latency, err
+}
+
+// probeBlobber checks a blobber responds
+func probeBlobber(ctx context.Context, client *http.Client, url string) (time.Duration, error) {
+	return getJSON(ctx, client, strings.TrimRight(url, "/")+"/_statsJSON", nil)
+}
+
+// getBuildTags gets the build tags of the miners and sharders registered with the miner smart contract,
+// by their id, from the first of sharders which responds with both lists
+func getBuildTags(ctx context.Context, client *http.Client, sharders []string) (map[string]string, error) {
+	var err error
+	for _, url := range sharders {
+		tags := make(map[string]string)
+		for _, list := range []string{zcncore.GET_MINERSC_MINERS, zcncore.GET_MINERSC_SHARDERS} {
+			var nodes zcncore.MinerSCNodes
+			if _, err = getJSON(ctx, client, strings.TrimRight(url, "/")+list, &nodes); err != nil {
+				break
+			}
+			for _, node := range nodes.Nodes {
+				tags[node.Miner.ID] = node.Miner.BuildTag
+			}
+		}
+		if err == nil {
+			return tags, nil
+		}
+	}
+	if err == nil {
+		err = errors.New("no sharder is up")
+	}
+	return nil, err
+}
+
+// setProbed sets the latency of node, and if it is up or else the error of the probe
+func (node *nodeStatus) setProbed(latency time.Duration, err error) {
+	node.LatencyMS = latency.Milliseconds()
+	if err != nil {
+		node.Error = err.Error()
+		return
+	}
+	node.Up = true
+}
+
+// probeNetwork probes the block worker, the miners and sharders of network (or else of the block worker),
+// and the blobbers, all at once
+func probeNetwork(ctx context.Context, timeout time.Duration, blockWorker string, network conf.Network, blobbers []*nodeStatus) []*nodeStatus {
+	client := &http.Client{Timeout: timeout}
+
+	var nodes []*nodeStatus
+	if len(blockWorker) > 0 {
+		bw := &nodeStatus{Type: nodeBlockWorker, URL: blockWorker}
+		bwNetwork, latency, err := probeBlockWorker(ctx, client, blockWorker)
+		bw.setProbed(latency, err)
+		if !network.IsValid() {
+			network = bwNetwork
+		}
+		nodes = append(nodes, bw)
+	}
+
+	blocks := make(map[*nodeStatus]*latestBlock)
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	probe := func(node *nodeStatus, get func() (time.Duration, error)) {
+		nodes = append(nodes, node)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node.setProbed(get())
+		}()
+	}
+	for _, url := range network.Miners {
+		url := url
+		node := &nodeStatus{Type: nodeMiner, URL: url}
+		probe(node, func() (time.Duration, error) {
+			id, latency, err := probeWhoami(ctx, client, url)
+			node.ID = id
+			return latency, err
+		})
+	}
+	for _, url := range network.Sharders {
+		url := url
+		node := &nodeStatus{Type: nodeSharder, URL: url}
+		probe(node, func() (time.Duration, error) {
+			block, latency, err := probeSharder(ctx, client, url)
+			if err == nil {
+				// the id only finds the build tag of the sharder, the sharder is up without it
+				node.ID, _, _ = probeWhoami(ctx, client, url)
+			}
+			mu.Lock()
+			blocks[node] = block
+			mu.Unlock()
+			return latency, err
+		})
+	}
+	for _, node := range blobbers {
+		url := node.URL
+		probe(node, func() (time.Duration, error) {
+			return probeBlobber(ctx, client, url)
+		})
+	}
+	wg.Wait()
+
+	// the build tags of the miners and sharders which are up, compared to the most common
+	var upSharders []string
+	for node := range blocks {
+		if node.Up {
+			upSharders = append(upSharders, node.URL)
+		}
+	}
+	sort.Strings(upSharders)
+	builds := make(map[string]int)
+	if tags, err := getBuildTags(ctx, client, upSharders); err == nil {
+		for _, node := range nodes {
+			if (node.Type == nodeMiner || node.Type == nodeSharder) && node.Up && len(tags[node.ID]) > 0 {
+				node.BuildTag = tags[node.ID]
+				builds[node.BuildTag]++
+			}
+		}
+	}
+	commonBuild := mostCommon(builds)
+
+	// the chain height and block version of the sharders, compared to the highest and the most common
+	var maxHeight int64
+	versions := make(map[string]int)
+	for node, block := range blocks {
+		if !node.Up || block.Round == 0 {
+			continue
+		}
+		node.Height, node.BlockVersion = block.Round, block.Version
+		if block.Round > maxHeight {
+			maxHeight = block.Round
+		}
+		versions[block.Version]++
+	}
+	commonVersion := mostCommon(versions)
+
+	for _, node := range nodes {
+		var notes []string
+		if len(builds) > 1 && len(node.BuildTag) > 0 && node.BuildTag != commonBuild {
+			notes = append(notes, "build "+node.BuildTag+", most miners and sharders are on "+commonBuild)
+		}
+		if node.Height > 0 && node.Height < maxHeight {
+			notes = append(notes, fmt.Sprintf("%d rounds behind", maxHeight-node.Height))
+		}
+		if len(versions) > 1 && node.Height > 0 && node.BlockVersion != commonVersion {
+			notes = append(notes, "block version "+node.BlockVersion+", most sharders are on "+commonVersion)
+		}
+		if len(notes) > 0 {
+			node.Note = strings.Join(notes, ", ")
+		}
+	}
+	return nodes
+}
+
+// mostCommon returns the value counted the most times, the greatest of those counted as many times
+func mostCommon(counts map[string]int) string {
+	var common string
+	for v, n := range counts {
+		if n > counts[common] || (n == counts[common] && v > common) {
+			common = v
+		}
+	}
+	return common
+}
+
+// countUp returns the nodes of type t, and how many of them are up
+func countUp(nodes []*nodeStatus, t string) (up, total int) {
+	for _, node := range nodes {
+		if node.Type == t {
+			total++
+			if node.Up {
+				up++
+			}
+		}
+	}
+	return up, total
+}
+
+var networkCmd = &cobra.Command{
+	Use:         "network",
+	Short:       "Network diagnostics",
+	Long:        `Network diagnostics, see zbox network status`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+var networkStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Probe the block worker, miners, sharders and blobbers",
+	Long: `Probe the block worker, the miners and sharders of network.yaml or of the block worker,
+and with --allocation the blobbers of the allocation. For each node it reports if it is up, its latency,
+for miners and sharders the build tag of their software, as registered with the miner smart contract, and for sharders
+the height of the chain and the block version of their latest finalized block. It notes miners and sharders on another
+build than most, sharders behind the others, and sharders on another block version than most.
+The software version of blobbers is not reported.
+Exits with the network exit code if no miner or no sharder is up, or if fewer blobbers are up than the data shards of the allocation.`,
+	// the network is probed before the sdk is initialized, which fails when the block worker is down
+	Annotations: map[string]string{annotationOffline: "true", annotationAllocationFilter: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		if timeout <= 0 {
+			return usageError("Error: timeout must be positive")
+		}
+		allocationID := cmd.Flag("allocation").Value.String()
+
+		configDir, err := resolveConfigDir()
+		if err != nil {
+			return configError("Error:", err)
+		}
+		cfg, _, err := loadConfig(configDir)
+		if err != nil {
+			return configError("Can't read config, see zbox config validate:", err)
+		}
+		network, _ := conf.LoadNetworkFile(getNetworkFilePath(configDir))
+
+		var health *allocationHealth
+		var blobbers []*nodeStatus
+		if len(allocationID) > 0 {
+			if err := initConfig(); err != nil {
+				return err
+			}
+			allocationObj, err := sdk.GetAllocation(allocationID)
+			if err != nil {
+				return commandError("Error fetching the allocation", err)
+			}
+			health = &allocationHealth{ID: allocationObj.ID, DataShards: allocationObj.DataShards, ParityShards: allocationObj.ParityShards}
+			for _, b := range allocationObj.Blobbers {
+				blobbers = append(blobbers, &nodeStatus{Type: nodeBlobber, URL: b.Baseurl, ID: b.ID})
+			}
+		}
+
+		status := &networkStatus{Nodes: probeNetwork(cmd.Context(), timeout, cfg.BlockWorker, network, blobbers)}
+		order := map[string]int{nodeBlockWorker: 0, nodeMiner: 1, nodeSharder: 2, nodeBlobber: 3}
+		sort.SliceStable(status.Nodes, func(i, j int) bool {
+			a, b := status.Nodes[i], status.Nodes[j]
+			if order[a.Type] != order[b.Type] {
+				return order[a.Type] < order[b.Type]
+			}
+			return a.URL < b.URL
+		})
+
+		minersUp, miners := countUp(status.Nodes, nodeMiner)
+		shardersUp, sharders := countUp(status.Nodes, nodeSharder)
+		status.OK = minersUp > 0 && shardersUp > 0
+		if health != nil {
+			health.BlobbersUp, health.Blobbers = countUp(status.Nodes, nodeBlobber)
+			health.Readable = health.BlobbersUp >= health.DataShards
+			status.OK = status.OK && health.Readable
+			status.Allocation = health
+		}
+
+		header := []string{"type", "url", "id", "up", "latency_ms", "height", "build_tag", "block_version", "note", "error"}
+		data := make([][]string, 0, len(status.Nodes))
+		for _, n := range status.Nodes {
+			data = append(data, []string{n.Type, n.URL, n.ID, strconv.FormatBool(n.Up), strconv.FormatInt(n.LatencyMS, 10),
+				strconv.FormatInt(n.Height, 10), n.BuildTag, n.BlockVersion, n.Note, n.Error})
+		}
+		printOutput(cmd, status, header, data, func() {
+			rows := make([][]string, 0, len(status.Nodes))
+			for _, n := range status.Nodes {
+				state, latency, height := "DOWN", "", ""
+				if n.Up {
+					state = "UP"
+					latency = (time.Duration(n.LatencyMS) * time.Millisecond).String()
+				}
+				if n.Height > 0 {
+					height = strconv.FormatInt(n.Height, 10)
+				}
+				note := n.Note
+				if len(n.Error) > 0 {
+					note = n.Error
+				}
+				rows = append(rows, []string{n.Type, n.URL, state, latency, height, n.BuildTag, n.BlockVersion, note})
+			}
+			util.WriteTable(os.Stdout, []string{"Type", "URL", "Status", "Latency", "Height", "Build", "Block Version", "Note"}, []string{}, rows)
+			fmt.Printf("Miners: %d/%d up, sharders: %d/%d up\n", minersUp, miners, shardersUp, sharders)
+			if health != nil {
+				fmt.Printf("Allocation %s: %d/%d blobbers up, %d needed for the data shards\n", health.ID, health.BlobbersUp, health.Blobbers, health.DataShards)
+			}
+		})
+
+		if err := interruptedError(cmd.Context()); err != nil {
+			return err
+		}
+		switch {
+		case minersUp == 0 || shardersUp == 0:
+			return newError(kindNetwork, fmt.Sprintf("Error: %d/%d miners and %d/%d sharders are up", minersUp, miners, shardersUp, sharders))
+		case health != nil && !health.Readable:
+			return newError(kindNetwork, fmt.Sprintf("Error: %d blobbers of the allocation are up, fewer than its %d data shards", health.BlobbersUp, health.DataShards))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(networkCmd)
+	networkCmd.AddCommand(networkStatusCmd)
+	networkStatusCmd.Flags().String("allocation", "", "Allocation ID, to probe its blobbers")
+	networkStatusCmd.Flags().Duration("timeout", 10*time.Second, "time each node has to respond")
+}
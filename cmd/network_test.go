@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0chain/gosdk/core/conf"
+	"github.com/0chain/gosdk/zcncore"
+)
+
+func TestProbeNetworkNeeds2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/miner/_nh/whoami", "/blobber/_statsJSON":
+			w.Write([]byte(`{}`))
+		case "/sharder/v1/block/get/latest_finalized":
+			w.Write([]byte(`{"round":10,"chain_id":"chain"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	network := conf.Network{Miners: []string{srv.URL + "/miner", srv.URL + "/missing"}, Sharders: []string{srv.URL + "/sharder"}}
+	blobbers := []*nodeStatus{{Type: nodeBlobber, URL: srv.URL + "/blobber"}, {Type: nodeBlobber, URL: srv.URL + "/notablobber"}}
+	nodes := probeNetwork(context.Background(), 5*time.Second, "", network, blobbers)
+
+	want := map[string]bool{
+		srv.URL + "/miner":       true,
+		srv.URL + "/missing":     false,
+		srv.URL + "/sharder":     true,
+		srv.URL + "/blobber":     true,
+		srv.URL + "/notablobber": false,
+	}
+	if len(nodes) != len(want) {
+		t.Fatalf("probed %d nodes, want %d", len(nodes), len(want))
+	}
+	for _, node := range nodes {
+		if node.Up != want[node.URL] {
+			t.Errorf("%s %s is up: %v, want %v (%s)", node.Type, node.URL, node.Up, want[node.URL], node.Error)
+		}
+	}
+}
+
+func TestProbeNetworkNotesBuildSkew(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/miner01/_nh/whoami":
+			w.Write([]byte("miner,127.0.0.1,7071,miner01,key01"))
+		case "/miner02/_nh/whoami":
+			w.Write([]byte("miner,127.0.0.1,7072,miner02,key02"))
+		case "/sharder01/_nh/whoami":
+			w.Write([]byte("sharder,127.0.0.1,7171,sharder01,key03"))
+		case "/sharder01/v1/block/get/latest_finalized":
+			w.Write([]byte(`{"round":10,"version":"1.0","chain_id":"chain"}`))
+		case "/sharder01" + zcncore.GET_MINERSC_MINERS:
+			w.Write([]byte(`{"Nodes":[{"simple_miner":{"id":"miner01","build_tag":"v1.2.3"}},{"simple_miner":{"id":"miner02","build_tag":"v1.2.2"}}]}`))
+		case "/sharder01" + zcncore.GET_MINERSC_SHARDERS:
+			w.Write([]byte(`{"Nodes":[{"simple_miner":{"id":"sharder01","build_tag":"v1.2.3"}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	network := conf.Network{Miners: []string{srv.URL + "/miner01", srv.URL + "/miner02"}, Sharders: []string{srv.URL + "/sharder01"}}
+	nodes := probeNetwork(context.Background(), 5*time.Second, "", network, nil)
+
+	want := map[string]struct{ build, note string }{
+		srv.URL + "/miner01":   {"v1.2.3", ""},
+		srv.URL + "/miner02":   {"v1.2.2", "build v1.2.2, most miners and sharders are on v1.2.3"},
+		srv.URL + "/sharder01": {"v1.2.3", ""},
+	}
+	for _, node := range nodes {
+		if w := want[node.URL]; node.BuildTag != w.build || node.Note != w.note {
+			t.Errorf("%s %s has build %q and note %q, want %q and %q", node.Type, node.URL, node.BuildTag, node.Note, w.build, w.note)
+		}
+	}
+}
@@ -17,7 +17,6 @@ import (
 
 	"github.com/0chain/gosdk/zboxcore/sdk"
 	"github.com/0chain/gosdk/zcncore"
-	"github.com/0chain/zboxcli/util"
 	"github.com/spf13/cobra"
 )
 
@@ -51,28 +50,36 @@ var newallocationCmd = &cobra.Command{
 	Short: "Creates a new allocation",
 	Long:  `Creates a new allocation`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var flags = cmd.Flags()
 		costOnly, _ := cmd.Flags().GetBool("cost")
+		alias, _ := flags.GetString("alias")
+		if len(alias) > 0 {
+			if err := validateAllocationAlias(alias); err != nil {
+				return usageError("Error:", err)
+			}
+		}
 
 		if flags.Changed("free_storage") {
 			if costOnly {
 				log.Print("Cost for the given allocation: ", 0)
-				return
+				return nil
+			}
+			lock, freeStorageMarker, err := processFreeStorageFlags(flags)
+			if err != nil {
+				return err
 			}
-			lock, freeStorageMarker := processFreeStorageFlags(flags)
 
 			allocationID, err := sdk.CreateFreeAllocation(freeStorageMarker, lock)
 			if err != nil {
-				log.Fatal("Error creating free allocation: ", err)
+				return commandError("Error creating free allocation: ", err)
 			}
 			log.Print("Allocation created: ", allocationID)
-			storeAllocation(allocationID)
-			return
+			return storeAllocation(allocationID, alias)
 		}
 
 		if datashards == nil || parityshards == nil || size == nil {
-			log.Fatal("Invalid allocation parameters.")
+			return commandError("Invalid allocation parameters.")
 		}
 
 		var (
@@ -82,7 +89,7 @@ var newallocationCmd = &cobra.Command{
 
 		if !costOnly {
 			if !flags.Changed("lock") {
-				log.Fatal("missing required 'lock' argument")
+				return usageError("missing required 'lock' argument")
 			}
 		}
 
@@ -90,13 +97,13 @@ var newallocationCmd = &cobra.Command{
 
 		var lockf float64
 		if lockf, err = flags.GetFloat64("lock"); err != nil {
-			log.Fatal("error: invalid 'lock' value:", err)
+			return usageError("error: invalid 'lock' value:", err)
 		}
 
 		if convertFromUSD {
 			lockf, err = zcncore.ConvertUSDToToken(lockf)
 			if err != nil {
-				log.Fatal("error: failed to convert to USD : ", err)
+				return commandError("error: failed to convert to USD : ", err)
 			}
 		}
 		lock = zcncore.ConvertToValue(lockf)
@@ -111,11 +118,11 @@ var newallocationCmd = &cobra.Command{
 		if flags.Changed("read_price") {
 			rps, err := flags.GetString("read_price")
 			if err != nil {
-				log.Fatal("invalid read_price value: ", err)
+				return usageError("invalid read_price value: ", err)
 			}
 			pr, err := getPriceRange(rps)
 			if err != nil {
-				log.Fatal("invalid read_price value: ", err)
+				return usageError("invalid read_price value: ", err)
 			}
 			readPrice = pr
 		}
@@ -123,27 +130,27 @@ var newallocationCmd = &cobra.Command{
 		if flags.Changed("write_price") {
 			wps, err := flags.GetString("write_price")
 			if err != nil {
-				log.Fatal("invalid write_price value: ", err)
+				return usageError("invalid write_price value: ", err)
 			}
 			pr, err := getPriceRange(wps)
 			if err != nil {
-				log.Fatal("invalid write_price value: ", err)
+				return usageError("invalid write_price value: ", err)
 			}
 			writePrice = pr
 		}
 
 		if flags.Changed("mcct") {
 			if mcct, err = flags.GetDuration("mcct"); err != nil {
-				log.Fatal("invalid mcct value: ", err)
+				return usageError("invalid mcct value: ", err)
 			}
 			if mcct <= 1*time.Second {
-				log.Fatal("invalid mcct value < 1s")
+				return usageError("invalid mcct value < 1s")
 			}
 		}
 
 		var expire time.Duration
 		if expire, err = flags.GetDuration("expire"); err != nil {
-			log.Fatal("invalid 'expire' flag: ", err)
+			return usageError("invalid 'expire' flag: ", err)
 		}
 
 		var expireAt = time.Now().Add(expire).Unix()
@@ -151,17 +158,17 @@ var newallocationCmd = &cobra.Command{
 		if costOnly {
 			minCost, err := sdk.GetAllocationMinLock(*datashards, *parityshards, *size, expireAt, readPrice, writePrice, mcct)
 			if err != nil {
-				log.Fatal("Error fetching cost: ", err)
+				return commandError("Error fetching cost: ", err)
 			}
 			log.Print("Cost for the given allocation: ", zcncore.ConvertToToken(minCost))
 
-			return
+			return nil
 		}
 
 		var owner string
 		if flags.Changed("owner") {
 			if owner, err = flags.GetString("owner"); err != nil {
-				log.Fatal("invalid owner value: ", err)
+				return usageError("invalid owner value: ", err)
 			}
 		}
 		var allocationID string
@@ -169,58 +176,57 @@ var newallocationCmd = &cobra.Command{
 			allocationID, err = sdk.CreateAllocation(*datashards, *parityshards,
 				*size, expireAt, readPrice, writePrice, mcct, lock)
 			if err != nil {
-				log.Fatal("Error creating allocation: ", err)
+				return commandError("Error creating allocation: ", err)
 			}
 		} else {
 			var ownerPublicKey string
 			if flags.Changed("owner") {
 				if ownerPublicKey, err = flags.GetString("owner_public_key"); err != nil {
-					log.Fatal("invalid owner public key: ", err)
+					return usageError("invalid owner public key: ", err)
 				}
 				if len(ownerPublicKey) == 0 {
-					log.Fatal("must provide owner public key, when creating an allocation for another")
+					return usageError("must provide owner public key, when creating an allocation for another")
 				}
 			}
 
 			allocationID, err = sdk.CreateAllocationForOwner(owner, ownerPublicKey, *datashards, *parityshards,
 				*size, expireAt, readPrice, writePrice, mcct, lock, blockchain.GetPreferredBlobbers())
 			if err != nil {
-				log.Fatal("Error creating allocation: ", err)
+				return commandError("Error creating allocation: ", err)
 			}
 		}
 		log.Print("Allocation created: ", allocationID)
-		storeAllocation(allocationID)
-
+		return storeAllocation(allocationID, alias)
 	},
 }
 
-func processFreeStorageFlags(flags *pflag.FlagSet) (int64, string) {
+func processFreeStorageFlags(flags *pflag.FlagSet) (int64, string, error) {
 	if flags.Changed("read_price") {
-		log.Fatal("free storage, read_price is predefined")
+		return 0, "", usageError("free storage, read_price is predefined")
 	}
 	if flags.Changed("write_price") {
-		log.Fatal("free storage, write_price is predefined")
+		return 0, "", usageError("free storage, write_price is predefined")
 	}
 	if flags.Changed("mcct") {
-		log.Fatal("free storage, mcct is predefined")
+		return 0, "", usageError("free storage, mcct is predefined")
 	}
 
 	filename, err := flags.GetString("free_storage")
 	if err != nil {
-		log.Fatal("invalid free)value: ", err)
+		return 0, "", usageError("invalid free)value: ", err)
 	}
 	freeStorageMarker, err := ioutil.ReadFile(filename)
 	if err != nil {
-		log.Fatal("cannot read in free_storage file", err)
+		return 0, "", commandError("cannot read in free_storage file", err)
 	}
 	var marker struct {
 		FreeTokens float64 `json:"free_tokens"`
 	}
 	err = json.Unmarshal(freeStorageMarker, &marker)
 	if err != nil {
-		log.Fatal("unmarshalling marker", err)
+		return 0, "", commandError("unmarshalling marker", err)
 	}
-	return zcncore.ConvertToValue(marker.FreeTokens), string(freeStorageMarker)
+	return zcncore.ConvertToValue(marker.FreeTokens), string(freeStorageMarker), nil
 }
 
 func init() {
@@ -254,22 +260,38 @@ func init() {
 			"json file containing marker for free storage")
 	newallocationCmd.Flags().String("owner", "",
 		"create an allocation with someone else as owner")
+	newallocationCmd.Flags().String("alias", "",
+		"save an alias of the new allocation, accepted anywhere --allocation is")
 	newallocationCmd.Flags().String("owner_public_key", "",
 		"public key of owner, user when creating an allocation for somone else")
 
 }
 
-func storeAllocation(allocationID string) {
+// storeAllocation saves allocationID as the default allocation of the config directory, and as alias if it is set
+func storeAllocation(allocationID, alias string) error {
+	configDir, err := resolveConfigDir()
+	if err != nil {
+		return configError("Error saving the allocation id.", err)
+	}
+	if len(alias) > 0 {
+		aliases, err := loadAllocationAliases()
+		if err == nil {
+			aliases[alias] = allocationID
+			err = saveAllocationAliases(aliases)
+		}
+		if err != nil {
+			return commandError("Error saving the allocation alias.", err)
+		}
+	}
 
-	allocFilePath := util.GetConfigDir() + string(os.PathSeparator) + *allocationFileName
+	allocFilePath := configDir + string(os.PathSeparator) + *allocationFileName
 
 	file, err := os.Create(allocFilePath)
 	if err != nil {
-		PrintError(err.Error())
-		os.Exit(1)
+		return commandError("Error saving the allocation id.", err)
 	}
 	defer file.Close()
 	//Only one allocation ID per file.
 	fmt.Fprintf(file, allocationID)
-
+	return nil
 }
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/0chain/zboxcli/util"
+	"github.com/spf13/cobra"
+)
+
+// output formats of read commands, see the root --output flag
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputCSV   = "csv"
+)
+
+var outputFormat string
+
+// validateFormats checks the root --output and --error_format flags before a command runs
+func validateFormats() error {
+	switch strings.ToLower(outputFormat) {
+	case "", outputTable, outputJSON, outputYAML, outputCSV:
+	default:
+		return usageError("Error: invalid output format " + outputFormat + ", valid formats are table, json, yaml and csv")
+	}
+	switch errorFormat {
+	case errorFormatText, errorFormatJSON:
+	default:
+		// the error is printed as text
+		format := errorFormat
+		errorFormat = errorFormatText
+		return usageError("Error: invalid error format " + format + ", valid formats are text and json")
+	}
+	return nil
+}
+
+// getOutputFormat returns the --output format of cmd. The --json flag some commands have is the same as --output json.
+func getOutputFormat(cmd *cobra.Command) string {
+	if doJSON, err := cmd.Flags().GetBool("json"); err == nil && doJSON {
+		return outputJSON
+	}
+	if len(outputFormat) == 0 {
+		return outputTable
+	}
+	return strings.ToLower(outputFormat)
+}
+
+// printOutput prints the result of a read command in the --output format of cmd.
+// v is printed as json and yaml, header and data as csv, and printTable is called for table output.
+// header names are snake case, and match the json names of the fields where v defines them,
+// so v must have json tags. An empty list is printed as [], even if v is a nil slice.
+func printOutput(cmd *cobra.Command, v interface{}, header []string, data [][]string, printTable func()) {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+		v = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+	switch getOutputFormat(cmd) {
+	case outputJSON:
+		util.PrintJSON(v)
+	case outputYAML:
+		util.PrintYAML(v)
+	case outputCSV:
+		util.WriteCSV(os.Stdout, header, data)
+	default:
+		printTable()
+	}
+}
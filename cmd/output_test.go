@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/spf13/cobra"
+)
+
+// captureStdout returns what run prints to stdout
+func captureStdout(t *testing.T, run func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	run()
+	w.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestPrintOutputEmptyList(t *testing.T) {
+	defer func(format string) { outputFormat = format }(outputFormat)
+	var pools []*sdk.AllocationPoolStat
+	for format, want := range map[string]string{outputJSON: "[]\n", outputYAML: "[]\n"} {
+		outputFormat = format
+		got := captureStdout(t, func() {
+			printOutput(&cobra.Command{}, pools, allocationPoolCSVHeader, allocationPoolCSVData(pools), func() {})
+		})
+		if got != want {
+			t.Errorf("%s output of no pools is %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestFileMetaOutputNames(t *testing.T) {
+	ref := &sdk.ConsolidatedFileMeta{Type: "f", Name: "a.txt", Path: "/a.txt", LookupHash: "lookup", Hash: "hash", MimeType: "text/plain", Size: 1}
+	b, err := json.Marshal(newFileMetaOutput(ref))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(b, &fields); err != nil {
+		t.Fatal(err)
+	}
+	// the csv header of meta names the same fields as its json
+	for _, name := range metaCSVHeader {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("json of meta has no %s: %s", name, b)
+		}
+	}
+	for name, v := range fields {
+		if strings.ToLower(name) != name {
+			t.Errorf("json of meta names %s in camel case", name)
+		}
+		if v == nil {
+			t.Errorf("json of meta has %s null", name)
+		}
+	}
+}
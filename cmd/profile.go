@@ -0,0 +1,469 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/0chain/gosdk/core/conf"
+	"github.com/0chain/zboxcli/util"
+	"github.com/spf13/cobra"
+)
+
+// defaultProfile is the name of the files directly in the config directory, used when no profile is selected
+const defaultProfile = "default"
+
+// profile files, the same names as in the config directory
+const (
+	profileConfigFile  = "config.yaml"
+	profileNetworkFile = "network.yaml"
+	profileWalletFile  = "wallet.json"
+)
+
+// profileName is the --profile flag
+var profileName string
+
+var profileNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// profilesDir holds a directory for each profile, with its config.yaml, network.yaml and wallet.json
+func profilesDir() string {
+	return filepath.Join(util.GetConfigDir(), "profiles")
+}
+
+// currentProfilePath keeps the name of the profile used when --profile is not set
+func currentProfilePath() string {
+	return filepath.Join(util.GetConfigDir(), "profile")
+}
+
+func profileDir(name string) string {
+	if name == defaultProfile {
+		return util.GetConfigDir()
+	}
+	return filepath.Join(profilesDir(), name)
+}
+
+func validateProfileName(name string) error {
+	if !profileNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q, use letters, digits, '_', '.' and '-'", name)
+	}
+	return nil
+}
+
+func profileExists(name string) bool {
+	if name == defaultProfile {
+		return true
+	}
+	info, err := os.Stat(profileDir(name))
+	return err == nil && info.IsDir()
+}
+
+// currentProfile returns the profile saved by `profile use`, or defaultProfile if there is none
+func currentProfile() (string, error) {
+	buf, err := ioutil.ReadFile(currentProfilePath())
+	if os.IsNotExist(err) {
+		return defaultProfile, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(string(buf))
+	if len(name) == 0 {
+		return defaultProfile, nil
+	}
+	return name, nil
+}
+
+func setCurrentProfile(name string) error {
+	if name == defaultProfile {
+		if err := os.Remove(currentProfilePath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return ioutil.WriteFile(currentProfilePath(), []byte(name+"\n"), 0644)
+}
+
+// activeProfile returns the --profile flag, or the current profile if the flag is not set
+func activeProfile() (string, error) {
+	name := profileName
+	if len(name) == 0 {
+		var err error
+		if name, err = currentProfile(); err != nil {
+			return "", err
+		}
+	}
+	if err := validateProfileName(name); err != nil {
+		return "", err
+	}
+	if !profileExists(name) {
+		return "", fmt.Errorf("profile %s does not exist, see zbox profile list", name)
+	}
+	return name, nil
+}
+
+// resolveConfigDir returns the directory config, network and wallet files are read from:
+// --configDir if set, otherwise the directory of the active profile
+func resolveConfigDir() (string, error) {
+	if len(cDir) > 0 {
+		if len(profileName) > 0 {
+			return "", errors.New("--profile and --configDir can not be used together")
+		}
+		return cDir, nil
+	}
+	name, err := activeProfile()
+	if err != nil {
+		return "", err
+	}
+	return profileDir(name), nil
+}
+
+// profileInfo is what list and show print of a profile
+type profileInfo struct {
+	Name        string `json:"name"`
+	Current     bool   `json:"current"`
+	Dir         string `json:"dir"`
+	BlockWorker string `json:"block_worker"`
+	ChainID     string `json:"chain_id"`
+	Network     bool   `json:"network"`
+	ClientID    string `json:"client_id"`
+	Error       string `json:"error,omitempty"`
+}
+
+func loadProfileInfo(name, current string) profileInfo {
+	dir := profileDir(name)
+	info := profileInfo{Name: name, Current: name == current, Dir: dir}
+
+	cfg, err := conf.LoadConfigFile(filepath.Join(dir, profileConfigFile))
+	if err != nil {
+		info.Error = err.Error()
+	}
+	info.BlockWorker = cfg.BlockWorker
+	info.ChainID = cfg.ChainID
+
+	if _, err = os.Stat(filepath.Join(dir, profileNetworkFile)); err == nil {
+		info.Network = true
+	}
+
+	// the client id of an encrypted wallet is not known without its passphrase
+	if buf, err := ioutil.ReadFile(filepath.Join(dir, profileWalletFile)); err == nil && isEncryptedWallet(buf) {
+		info.ClientID = "encrypted"
+	} else if err == nil {
+		var wallet struct {
+			ClientID string `json:"client_id"`
+		}
+		if err = json.Unmarshal(buf, &wallet); err != nil {
+			info.Error = "invalid wallet: " + err.Error()
+		}
+		info.ClientID = wallet.ClientID
+	}
+	return info
+}
+
+// listProfiles returns the names of all profiles, defaultProfile first
+func listProfiles() ([]string, error) {
+	names := []string{defaultProfile}
+	files, err := ioutil.ReadDir(profilesDir())
+	if os.IsNotExist(err) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.IsDir() && f.Name() != defaultProfile && validateProfileName(f.Name()) == nil {
+			names = append(names, f.Name())
+		}
+	}
+	return names, nil
+}
+
+var profileCSVHeader = []string{"name", "current", "dir", "block_worker", "chain_id", "network", "client_id"}
+
+func profileCSVRow(p profileInfo) []string {
+	return []string{p.Name, strconv.FormatBool(p.Current), p.Dir, p.BlockWorker, p.ChainID, strconv.FormatBool(p.Network), p.ClientID}
+}
+
+// copyFile copies src to dst, and fails if dst exists
+func copyFile(src, dst string, perm os.FileMode) error {
+	buf, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// profileCmd represents the profile command group
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named profiles of config, network and wallet",
+	Long: `Manage named profiles, each with its own config.yaml, network.yaml and wallet.json.
+The profile given by --profile, or else the current profile set by 'profile use', is used by every command.
+The "default" profile is the files directly in the config directory.`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a profile",
+	Long: `Create a profile, copying config and network from another profile or from the given files.
+The wallet is only copied when --wallet_file is set, otherwise create one with zbox wallet create --profile <name>.`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fflags := cmd.Flags()
+		name := args[0]
+		if err := validateProfileName(name); err != nil {
+			return usageError("Error:", err)
+		}
+		if profileExists(name) {
+			return commandError("Error: profile " + name + " already exists")
+		}
+
+		from, _ := fflags.GetString("from")
+		if !profileExists(from) {
+			return notFoundError("Error: profile " + from + " does not exist")
+		}
+		configFile, _ := fflags.GetString("config_file")
+		if len(configFile) == 0 {
+			configFile = filepath.Join(profileDir(from), profileConfigFile)
+		}
+		if _, err := conf.LoadConfigFile(configFile); err != nil {
+			return configError("Error: invalid config "+configFile+":", err)
+		}
+
+		files := map[string]string{profileConfigFile: configFile}
+		if networkFile, _ := fflags.GetString("network_file"); len(networkFile) > 0 {
+			files[profileNetworkFile] = networkFile
+		} else if fromNetwork := filepath.Join(profileDir(from), profileNetworkFile); fileExists(fromNetwork) {
+			files[profileNetworkFile] = fromNetwork
+		}
+		if walletFile, _ := fflags.GetString("wallet_file"); len(walletFile) > 0 {
+			files[profileWalletFile] = walletFile
+		}
+
+		dir := profileDir(name)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return commandError("Error:", err)
+		}
+		for dst, src := range files {
+			// the wallet holds the private key
+			perm := os.FileMode(0644)
+			if dst == profileWalletFile {
+				perm = 0600
+			}
+			if err := copyFile(src, filepath.Join(dir, dst), perm); err != nil {
+				os.RemoveAll(dir)
+				return commandError("Error copying "+src+":", err)
+			}
+		}
+		fmt.Println("Profile " + name + " created in " + dir)
+
+		if use, _ := fflags.GetBool("use"); use {
+			if err := setCurrentProfile(name); err != nil {
+				return commandError("Error:", err)
+			}
+			fmt.Println("Switched to profile " + name)
+		}
+		return nil
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List profiles",
+	Long:        `List profiles, the current profile is marked with *`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := listProfiles()
+		if err != nil {
+			return commandError("Error:", err)
+		}
+		current, err := currentProfile()
+		if err != nil {
+			return commandError("Error:", err)
+		}
+
+		profiles := make([]profileInfo, 0, len(names))
+		data := make([][]string, 0, len(names))
+		for _, name := range names {
+			p := loadProfileInfo(name, current)
+			profiles = append(profiles, p)
+			data = append(data, profileCSVRow(p))
+		}
+
+		printOutput(cmd, profiles, profileCSVHeader, data, func() {
+			header := []string{"", "NAME", "BLOCK WORKER", "CHAIN ID", "NETWORK", "CLIENT ID"}
+			rows := make([][]string, 0, len(profiles))
+			for _, p := range profiles {
+				mark := ""
+				if p.Current {
+					mark = "*"
+				}
+				clientID := p.ClientID
+				if len(clientID) == 0 {
+					clientID = "-"
+				}
+				blockWorker := p.BlockWorker
+				if len(p.Error) > 0 {
+					blockWorker = "invalid: " + p.Error
+				}
+				rows = append(rows, []string{mark, p.Name, blockWorker, p.ChainID, strconv.FormatBool(p.Network), clientID})
+			}
+			util.WriteTable(os.Stdout, header, []string{}, rows)
+		})
+		return nil
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:         "use <name>",
+	Short:       "Set the current profile",
+	Long:        `Set the profile used by commands run without --profile. Use "default" for the files directly in the config directory.`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := validateProfileName(name); err != nil {
+			return usageError("Error:", err)
+		}
+		if !profileExists(name) {
+			return notFoundError("Error: profile " + name + " does not exist, see zbox profile list")
+		}
+		if err := setCurrentProfile(name); err != nil {
+			return commandError("Error:", err)
+		}
+		fmt.Println("Switched to profile " + name)
+		return nil
+	},
+}
+
+var profileShowCmd = &cobra.Command{
+	Use:         "show [name]",
+	Short:       "Show a profile",
+	Long:        `Show a profile, the active one (--profile or the current profile) if no name is given`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var name string
+		var err error
+		if len(args) > 0 {
+			name = args[0]
+			if err = validateProfileName(name); err == nil && !profileExists(name) {
+				err = fmt.Errorf("profile %s does not exist, see zbox profile list", name)
+			}
+		} else {
+			name, err = activeProfile()
+		}
+		if err != nil {
+			return configError("Error:", err)
+		}
+		current, err := currentProfile()
+		if err != nil {
+			return commandError("Error:", err)
+		}
+
+		p := loadProfileInfo(name, current)
+		printOutput(cmd, p, profileCSVHeader, [][]string{profileCSVRow(p)}, func() {
+			fmt.Println("name:        ", p.Name)
+			fmt.Println("current:     ", p.Current)
+			fmt.Println("dir:         ", p.Dir)
+			fmt.Println("block_worker:", p.BlockWorker)
+			fmt.Println("chain_id:    ", p.ChainID)
+			fmt.Println("network:     ", p.Network)
+			fmt.Println("client_id:   ", p.ClientID)
+			if len(p.Error) > 0 {
+				fmt.Println("error:       ", p.Error)
+			}
+		})
+		return nil
+	},
+}
+
+var profileDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a profile",
+	Long: `Delete a profile and its files. The current profile, and a profile with a wallet, are only deleted with --force,
+as the wallet can not be recovered once it is deleted.`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if name == defaultProfile {
+			return commandError("Error: the default profile can not be deleted")
+		}
+		if err := validateProfileName(name); err != nil {
+			return usageError("Error:", err)
+		}
+		if !profileExists(name) {
+			return notFoundError("Error: profile " + name + " does not exist")
+		}
+		current, err := currentProfile()
+		if err != nil {
+			return commandError("Error:", err)
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		dir := profileDir(name)
+		if !force {
+			if name == current {
+				return commandError("Error: " + name + " is the current profile, use --force to delete it")
+			}
+			if fileExists(filepath.Join(dir, profileWalletFile)) {
+				return commandError("Error: profile " + name + " has a wallet, use --force to delete it")
+			}
+		}
+
+		if err = os.RemoveAll(dir); err != nil {
+			return commandError("Error:", err)
+		}
+		if name == current {
+			if err = setCurrentProfile(defaultProfile); err != nil {
+				return commandError("Error:", err)
+			}
+			fmt.Println("Switched to profile " + defaultProfile)
+		}
+		fmt.Println("Profile " + name + " deleted")
+		return nil
+	},
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileCreateCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileShowCmd)
+	profileCmd.AddCommand(profileDeleteCmd)
+
+	profileCreateCmd.Flags().String("from", defaultProfile, "profile to copy config and network from")
+	profileCreateCmd.Flags().String("config_file", "", "config file to copy, instead of the config of --from")
+	profileCreateCmd.Flags().String("network_file", "", "network file to copy, instead of the network of --from")
+	profileCreateCmd.Flags().String("wallet_file", "", "wallet file to copy, see zbox wallet create and zbox wallet recover if not set")
+	profileCreateCmd.Flags().Bool("use", false, "set the created profile as the current profile")
+
+	profileDeleteCmd.Flags().Bool("force", false, "delete the current profile, or a profile with a wallet")
+}
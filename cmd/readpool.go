@@ -2,11 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"strconv"
 	"time"
 
 	"github.com/0chain/gosdk/zboxcore/sdk"
-	"github.com/0chain/zboxcli/util"
 	"github.com/0chain/gosdk/zcncore"
 	"github.com/spf13/cobra"
 )
@@ -17,12 +16,13 @@ var rpCreate = &cobra.Command{
 	Short: "Create read pool if missing",
 	Long:  `Create read pool in storage SC if the pool is missing.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var err error
 		if err = sdk.CreateReadPool(); err != nil {
-			log.Fatalf("Failed to create read pool: %v\n", err)
+			return commandError("Failed to create read pool:", err)
 		}
 		fmt.Println("Read pool created successfully")
+		return nil
 	},
 }
 
@@ -42,13 +42,34 @@ func printReadPoolStat(stat []*sdk.AllocationPoolStat) {
 	fmt.Println()
 }
 
+// allocationPoolCSVHeader is the csv header of rp-info and wp-info, a row for each blobber of each pool
+var allocationPoolCSVHeader = []string{"id", "allocation_id", "balance", "expire_at", "locked", "blobber_id", "blobber_balance"}
+
+func allocationPoolCSVData(stat []*sdk.AllocationPoolStat) [][]string {
+	var data [][]string
+	for _, st := range stat {
+		for _, b := range st.Blobbers {
+			data = append(data, []string{
+				st.ID, string(st.AllocationID),
+				strconv.FormatInt(int64(st.Balance), 10),
+				strconv.FormatInt(int64(st.ExpireAt), 10),
+				strconv.FormatBool(st.Locked),
+				string(b.BlobberID),
+				strconv.FormatInt(int64(b.Balance), 10),
+			})
+		}
+	}
+	return data
+}
+
 // rpInfo information
 var rpInfo = &cobra.Command{
-	Use:   "rp-info",
-	Short: "Read pool information.",
-	Long:  `Read pool information.`,
-	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:         "rp-info",
+	Short:       "Read pool information.",
+	Long:        `Read pool information.`,
+	Annotations: map[string]string{annotationAllocationFilter: "true"},
+	Args:        cobra.MinimumNArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags   = cmd.Flags()
@@ -58,27 +79,24 @@ var rpInfo = &cobra.Command{
 
 		if flags.Changed("allocation") {
 			if allocID, err = flags.GetString("allocation"); err != nil {
-				log.Fatalf("can't get 'allocation' flag: %v", err)
+				return usageError("can't get 'allocation' flag:", err)
 			}
 		}
-		doJSON, _ := cmd.Flags().GetBool("json")
-
 
 		var info *sdk.AllocationPoolStats
 		if info, err = sdk.GetReadPoolInfo(""); err != nil {
-			log.Fatalf("Failed to get read pool info: %v", err)
+			return commandError("Failed to get read pool info:", err)
 		}
-		if len(info.Pools) == 0 {
+		if len(info.Pools) == 0 && getOutputFormat(cmd) == outputTable {
 			fmt.Println("no tokens locked")
-			return
+			return nil
 		}
 
 		info.AllocFilter(allocID)
-		if doJSON {
-			util.PrintJSON(info.Pools)
-			return
-		}
-		printReadPoolStat(info.Pools)
+		printOutput(cmd, info.Pools, allocationPoolCSVHeader, allocationPoolCSVData(info.Pools), func() {
+			printReadPoolStat(info.Pools)
+		})
+		return nil
 	},
 }
 
@@ -88,7 +106,7 @@ var rpLock = &cobra.Command{
 	Short: "Lock some tokens in read pool.",
 	Long:  `Lock some tokens in read pool.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags     = cmd.Flags()
@@ -101,47 +119,48 @@ var rpLock = &cobra.Command{
 		)
 
 		if !flags.Changed("duration") {
-			log.Fatal("missing required 'duration' flag")
+			return usageError("missing required 'duration' flag")
 		}
 
 		if !flags.Changed("allocation") {
-			log.Fatal("missing required 'allocation' flag")
+			return usageError("missing required 'allocation' flag")
 		}
 
 		if !flags.Changed("tokens") {
-			log.Fatal("missing required 'tokens' flag")
+			return usageError("missing required 'tokens' flag")
 		}
 
 		if duration, err = flags.GetDuration("duration"); err != nil {
-			log.Fatal("invalid 'duration' flag: ", err)
+			return usageError("invalid 'duration' flag: ", err)
 		}
 
 		if allocID, err = flags.GetString("allocation"); err != nil {
-			log.Fatal("invalid 'allocation' flag: ", err)
+			return usageError("invalid 'allocation' flag: ", err)
 		}
 
 		if flags.Changed("blobber") {
 			if blobberID, err = flags.GetString("blobber"); err != nil {
-				log.Fatal("invalid 'blobber' flag: ", err)
+				return usageError("invalid 'blobber' flag: ", err)
 			}
 		}
 
 		if tokens, err = flags.GetFloat64("tokens"); err != nil {
-			log.Fatal("invalid 'tokens' flag: ", err)
+			return usageError("invalid 'tokens' flag: ", err)
 		}
 
 		if flags.Changed("fee") {
 			if fee, err = flags.GetFloat64("fee"); err != nil {
-				log.Fatal("invalid 'fee' flag: ", err)
+				return usageError("invalid 'fee' flag: ", err)
 			}
 		}
 
 		err = sdk.ReadPoolLock(duration, allocID, blobberID,
 			zcncore.ConvertToValue(tokens), zcncore.ConvertToValue(fee))
 		if err != nil {
-			log.Fatalf("Failed to lock tokens in read pool: %v", err)
+			return commandError("Failed to lock tokens in read pool:", err)
 		}
 		fmt.Println("locked")
+		return nil
 	},
 }
 
@@ -151,7 +170,7 @@ var rpUnlock = &cobra.Command{
 	Short: "Unlock some expired tokens in a read pool.",
 	Long:  `Unlock some expired tokens in a read pool.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags  = cmd.Flags()
@@ -161,24 +180,25 @@ var rpUnlock = &cobra.Command{
 		)
 
 		if !flags.Changed("pool_id") {
-			log.Fatal("missing required 'pool_id' flag")
+			return usageError("missing required 'pool_id' flag")
 		}
 
 		if poolID, err = flags.GetString("pool_id"); err != nil {
-			log.Fatal("invalid 'pool_id' flag: ", err)
+			return usageError("invalid 'pool_id' flag: ", err)
 		}
 
 		if flags.Changed("fee") {
 			if fee, err = flags.GetFloat64("fee"); err != nil {
-				log.Fatal("invalid 'fee' flag: ", err)
+				return usageError("invalid 'fee' flag: ", err)
 			}
 		}
 
 		err = sdk.ReadPoolUnlock(poolID, zcncore.ConvertToValue(fee))
 		if err != nil {
-			log.Fatalf("Failed to unlock tokens in read pool: %v", err)
+			return commandError("Failed to unlock tokens in read pool:", err)
 		}
 		fmt.Println("unlocked")
+		return nil
 	},
 }
 
@@ -190,7 +210,7 @@ func init() {
 
 	rpInfo.PersistentFlags().String("allocation", "",
 		"allocation id, optional")
-	rpInfo.Flags().Bool("json", false, "pass this option to print response as json data")
+	rpInfo.Flags().Bool("json", false, "pass this option to print response as json data, same as --output json")
 
 	rpLock.PersistentFlags().Duration("duration", 0,
 		"lock duration, required")
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"sync"
 
 	"github.com/0chain/gosdk/zcncore"
@@ -15,10 +14,9 @@ var registerWalletCmd = &cobra.Command{
 	Short: "Registers the wallet with the blockchain",
 	Long:  `Registers the wallet with the blockchain`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if clientWallet == nil {
-			PrintError("Invalid wallet. Wallet not initialized in sdk")
-			os.Exit(1)
+			return commandError("Invalid wallet. Wallet not initialized in sdk")
 		}
 		wg := &sync.WaitGroup{}
 		statusBar := &ZCNStatus{wg: wg}
@@ -28,9 +26,9 @@ var registerWalletCmd = &cobra.Command{
 		if statusBar.success {
 			fmt.Println("Wallet registered")
 		} else {
-			PrintError("Wallet registration failed. " + statusBar.errMsg)
-			os.Exit(1)
+			return commandError("Wallet registration failed. " + statusBar.errMsg)
 		}
+		return nil
 	},
 }
 
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"sync"
 
 	"github.com/0chain/gosdk/zboxcore/sdk"
@@ -15,26 +14,22 @@ var renameCmd = &cobra.Command{
 	Short: "rename an object(file/folder) on blobbers",
 	Long:  `rename an object on blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags()                      // fflags is a *flag.FlagSet
 		if fflags.Changed("allocation") == false { // check if the flag "path" is set
-			PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-			os.Exit(1)                                      // and os.Exit(1)
+			return usageError("Error: allocation flag is missing")
 		}
 		if fflags.Changed("remotepath") == false {
-			PrintError("Error: remotepath flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath flag is missing")
 		}
 
 		if fflags.Changed("destname") == false {
-			PrintError("Error: destname flag is missing")
-			os.Exit(1)
+			return usageError("Error: destname flag is missing")
 		}
 		allocationID := cmd.Flag("allocation").Value.String()
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation", err)
 		}
 		remotepath := cmd.Flag("remotepath").Value.String()
 		destname := cmd.Flag("destname").Value.String()
@@ -42,8 +37,7 @@ var renameCmd = &cobra.Command{
 
 		statsMap, err := allocationObj.GetFileStats(remotepath)
 		if err != nil {
-			PrintError("Error in getting information about the object." + err.Error())
-			os.Exit(1)
+			return commandError("Error in getting information about the object.", err)
 		}
 		isFile := false
 		for _, v := range statsMap {
@@ -57,14 +51,12 @@ var renameCmd = &cobra.Command{
 		if isFile && commit {
 			fileMeta, err = allocationObj.GetFileMeta(remotepath)
 			if err != nil {
-				PrintError("Failed to fetch metadata for the given file", err.Error())
-				os.Exit(1)
+				return commandError("Failed to fetch metadata for the given file", err)
 			}
 		}
 		err = allocationObj.RenameObject(remotepath, destname)
 		if err != nil {
-			PrintError(err.Error())
-			os.Exit(1)
+			return commandError(err)
 		}
 		fmt.Println(remotepath + " renamed")
 
@@ -74,13 +66,17 @@ var renameCmd = &cobra.Command{
 				wg := &sync.WaitGroup{}
 				statusBar := &StatusBar{wg: wg}
 				wg.Add(1)
-				commitMetaTxn(remotepath, "Rename", "", "", allocationObj, fileMeta, statusBar)
+				if err := commitMetaTxn(remotepath, "Rename", "", "", allocationObj, fileMeta, statusBar); err != nil {
+					return err
+				}
 				wg.Wait()
 			} else {
-				commitFolderTxn("Rename", remotepath, destname, allocationObj)
+				if err := commitFolderTxn("Rename", remotepath, destname, allocationObj); err != nil {
+					return err
+				}
 			}
 		}
-		return
+		return nil
 	},
 }
 
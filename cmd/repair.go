@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"os"
 	"sync"
 
 	"github.com/0chain/gosdk/zboxcore/sdk"
@@ -14,25 +13,21 @@ var startRepair = &cobra.Command{
 	Short: "start repair file to blobbers",
 	Long:  `start repair file to blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags()                      // fflags is a *flag.FlagSet
 		if fflags.Changed("allocation") == false { // check if the flag "path" is set
-			PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-			os.Exit(1)                                      // and return
+			return usageError("Error: allocation flag is missing")
 		}
 		if fflags.Changed("rootpath") == false {
-			PrintError("Error: rootpath flag is missing")
-			os.Exit(1)
+			return usageError("Error: rootpath flag is missing")
 		}
 		if fflags.Changed("repairpath") == false {
-			PrintError("Error: repairpath flag is missing")
-			os.Exit(1)
+			return usageError("Error: repairpath flag is missing")
 		}
 		allocationID := cmd.Flag("allocation").Value.String()
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation.", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation.", err)
 		}
 		localRootPath := cmd.Flag("rootpath").Value.String()
 		repairPath := cmd.Flag("repairpath").Value.String()
@@ -44,14 +39,13 @@ var startRepair = &cobra.Command{
 		err = allocationObj.StartRepair(localRootPath, repairPath, statusBar)
 		if err != nil {
 			allocUnderRepair = false
-			PrintError("Repair failed.", err)
-			os.Exit(1)
+			return commandError("Repair failed.", err)
 		}
 		wg.Wait()
 		if !statusBar.success {
-			os.Exit(1)
+			return transferError(cmd.Context(), "Repair failed.", statusBar.err)
 		}
-		return
+		return nil
 	},
 }
 
@@ -1,20 +1,22 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"sync"
+	"strings"
+	"syscall"
 
 	"github.com/0chain/gosdk/core/conf"
 	"github.com/0chain/gosdk/core/logger"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/0chain/gosdk/zboxcore/blockchain"
-	"github.com/0chain/zboxcli/util"
 
 	"github.com/0chain/gosdk/core/zcncrypto"
 
@@ -37,59 +39,180 @@ var rootCmd = &cobra.Command{
 	Use:   "zbox",
 	Short: "zbox is a decentralized storage application written on the 0Chain platform",
 	Long: `zbox is a decentralized storage application written on the 0Chain platform.
-			Complete documentation is available at https://docs.0chain.net/0chain/`,
+			Complete documentation is available at https://docs.0chain.net/0chain/
+			Global flags and config.yaml keys can also be set by ZBOX_ environment variables, like ZBOX_BLOCK_WORKER.`,
 }
 
 var clientWallet *zcncrypto.Wallet
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	// errors are printed by handleError, and usage only for errors of flags and arguments
+	rootCmd.SilenceErrors = true
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		// the default allocation fills --allocation before required flags are checked
+		if err := applyAllocationFlag(cmd); err != nil {
+			return err
+		}
+		// cobra checks required flags only after this, so they are checked here to be usage errors
+		if err := validateRequiredFlags(cmd); err != nil {
+			return err
+		}
+		commandStarted = true
+		cmd.SilenceUsage = true
+		if err := validateFormats(); err != nil {
+			return err
+		}
+		if err := validateLogFlags(); err != nil {
+			return err
+		}
+		if isOfflineCommand(cmd) {
+			return nil
+		}
+		return initConfig()
+	}
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is config.yaml)")
 	rootCmd.PersistentFlags().StringVar(&networkFile, "network", "", "network file to overwrite the network details (if required, default is network.yaml)")
 	rootCmd.PersistentFlags().StringVar(&walletFile, "wallet", "", "wallet file (default is wallet.json)")
 	rootCmd.PersistentFlags().StringVar(&walletClientID, "wallet_client_id", "", "wallet client_id")
 	rootCmd.PersistentFlags().StringVar(&walletClientKey, "wallet_client_key", "", "wallet client_key")
 	rootCmd.PersistentFlags().StringVar(&cDir, "configDir", "", "configuration directory (default is $HOME/.zcn)")
+	rootCmd.PersistentFlags().IntVar(&walletPassphraseFD, "wallet_passphrase_fd", -1, "file descriptor to read the passphrase of an encrypted wallet from (default is $ZBOX_WALLET_PASSPHRASE, or a prompt)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "profile to use instead of the current profile, see zbox profile")
 	rootCmd.PersistentFlags().BoolVar(&bSilent, "silent", false, "Do not show interactive sdk logs (shown by default)")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", outputTable, "output format of read commands: table, json, yaml or csv")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "log file, relative to the config directory if not absolute (default is cmdlog.log, or log_file of the config)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "log level: none, fatal, error, info or debug (default is debug, or log_level of the config)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "", "log format: text or json (default is text, or log_format of the config)")
+	rootCmd.PersistentFlags().StringVar(&errorFormat, "error_format", errorFormatText, "format of errors printed on stderr: text or json")
 }
 
+// exitCodeInterrupted is the exit code of a command stopped by SIGINT or SIGTERM
+const exitCodeInterrupted = 130
+
+// Execute runs the command given by the arguments, and exits with the exit code of the error it returns, see exitCodes
 func Execute() {
+	registerCompletions(rootCmd)
+	if err := applyFlagEnv(); err != nil {
+		os.Exit(handleError(usageError("Error:", err)))
+	}
+
+	ctx, cancel := withSignalContext(context.Background())
+	defer cancel()
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	err := rootCmd.ExecuteContext(ctx)
+	if err == nil {
+		err = interruptedError(ctx)
+	}
+	if err != nil {
+		cancel()
+		os.Exit(handleError(err))
 	}
 }
 
-func initConfig() {
+// withSignalContext returns a context which is canceled on the first SIGINT/SIGTERM,
+// so commands stop starting new work and let in-flight work finish. The second signal exits immediately.
+func withSignalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-sigs:
+			PrintError("\nInterrupted, waiting for in-flight work to stop. Interrupt again to exit immediately.")
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+		<-sigs
+		os.Exit(exitCodeInterrupted)
+	}()
+
+	return ctx, cancel
+}
 
-	var configDir string
-	if cDir != "" {
-		configDir = cDir
-	} else {
-		configDir = util.GetConfigDir()
+// validateRequiredFlags returns the error cobra returns for required flags of cmd which are not set
+func validateRequiredFlags(cmd *cobra.Command) error {
+	var missing []string
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if required, ok := f.Annotations[cobra.BashCompOneRequiredFlag]; ok && required[0] == "true" && !f.Changed {
+			missing = append(missing, f.Name)
+		}
+	})
+	if len(missing) > 0 {
+		return fmt.Errorf(`required flag(s) "%s" not set`, strings.Join(missing, `", "`))
 	}
+	return nil
+}
 
+// annotationOffline marks commands which only work on local files, and run without a config, network or wallet
+const annotationOffline = "offline"
+
+// isOfflineCommand reports if cmd runs without initializing the sdk
+func isOfflineCommand(cmd *cobra.Command) bool {
+	// the help and __complete commands are added by cobra, and can not be annotated.
+	// Completions initialize the sdk themselves when they need the network, see initCompletionSDK.
+	return cmd.Annotations[annotationOffline] == "true" || cmd.Name() == "help" || cmd.Name() == cobra.ShellCompRequestCmd
+}
+
+// getConfigFilePath returns the --config file in configDir, config.yaml by default
+func getConfigFilePath(configDir string) string {
 	if cfgFile == "" {
 		cfgFile = "config.yaml"
 	}
-	cfg, err := conf.LoadConfigFile(filepath.Join(configDir, cfgFile))
+	return filepath.Join(configDir, cfgFile)
+}
+
+// getWalletFilePath returns the --wallet file, relative to configDir if it is not absolute, or wallet.json in configDir
+func getWalletFilePath(configDir string) string {
+	if len(walletFile) > 0 {
+		if filepath.IsAbs(walletFile) {
+			return walletFile
+		}
+		return filepath.Join(configDir, walletFile)
+	}
+	return filepath.Join(configDir, "wallet.json")
+}
+
+// initConfig initializes the sdk with the config, network and wallet of the active profile.
+// It runs before every command which is not offline.
+func initConfig() error {
+	configDir, err := resolveConfigDir()
+	if err != nil {
+		return configError("Error:", err)
+	}
+	// the wallet is read first, so a missing wallet or passphrase fails before any network request
+	if err = loadWallet(configDir); err != nil {
+		return err
+	}
+	cfg, network, err := initCoreSDK(configDir)
 	if err != nil {
-		fmt.Println("Can't read config:", err)
-		os.Exit(1)
+		return err
+	}
+	return initStorageSDK(cfg, network)
+}
+
+// initCoreSDK reads the config and network files in configDir, and initializes the core sdk with them
+func initCoreSDK(configDir string) (conf.Config, conf.Network, error) {
+	cfg, _, err := loadConfig(configDir)
+	if err != nil {
+		return cfg, conf.Network{}, configError("Can't read config:", err)
 	}
 
-	if networkFile == "" {
-		networkFile = "network.yaml"
+	// the network file is optional, but one which exists has to be valid
+	networkPath := getNetworkFilePath(configDir)
+	network, err := conf.LoadNetworkFile(networkPath)
+	if err != nil && fileExists(networkPath) {
+		return cfg, network, configError("Can't read network, see zbox config validate:", err)
 	}
-	network, _ := conf.LoadNetworkFile(filepath.Join(configDir, networkFile))
 
 	// syncing loggers
 	logger.SyncLoggers([]*logger.Logger{zcncore.GetLogger(), sdk.GetLogger()})
 
-	// set the log file
-	zcncore.SetLogFile("cmdlog.log", !bSilent)
-	sdk.SetLogFile("cmdlog.log", !bSilent)
+	if err = initLogging(configDir); err != nil {
+		return cfg, network, err
+	}
 
 	if network.IsValid() {
 		zcncore.SetNetwork(network.Miners, network.Sharders)
@@ -105,14 +228,28 @@ func initConfig() {
 		zcncore.WithMinConfirmation(cfg.MinConfirmation),
 		zcncore.WithConfirmationChainLength(cfg.ConfirmationChainLength))
 	if err != nil {
-		fmt.Println("Error initializing core SDK.", err)
-		os.Exit(1)
+		return cfg, network, newError(kindNetwork, "Error initializing core SDK.", err)
 	}
+	return cfg, network, nil
+}
 
-	// is freshly created wallet?
-	var fresh bool
-
+// loadWallet sets walletJSON and clientWallet from --wallet_client_id and --wallet_client_key, ZBOX_WALLET_JSON,
+// the environment variables of --wallet_client_id and --wallet_client_key, or else from the wallet file.
+// A missing wallet is not created, see zbox wallet create.
+func loadWallet(configDir string) error {
+	var err error
 	wallet := &zcncrypto.Wallet{}
+	if data := os.Getenv(walletJSONEnv); len(data) > 0 && !rootCmd.PersistentFlags().Changed("wallet_client_id") {
+		walletJSON, err = decodeWallet([]byte(data), walletJSONEnv)
+		if err != nil {
+			return configError("Error reading the wallet of "+walletJSONEnv, err)
+		}
+		if err = json.Unmarshal([]byte(walletJSON), wallet); err != nil || len(wallet.ClientID) == 0 {
+			return configError("Invalid wallet json in " + walletJSONEnv)
+		}
+		clientWallet = wallet
+		return nil
+	}
 	if (&walletClientID != nil) && (len(walletClientID) > 0) && (&walletClientKey != nil) && (len(walletClientKey) > 0) {
 		wallet.ClientID = walletClientID
 		wallet.ClientKey = walletClientKey
@@ -121,77 +258,36 @@ func initConfig() {
 		clientBytes, err = json.Marshal(wallet)
 		walletJSON = string(clientBytes)
 		if err != nil {
-			fmt.Println("Invalid wallet data passed:" + walletClientID + " " + walletClientKey)
-			os.Exit(1)
+			return configError("Invalid wallet data passed:" + walletClientID + " " + walletClientKey)
 		}
 		clientWallet = wallet
-		fresh = false
-	} else {
-		var walletFilePath string
-		if &walletFile != nil && len(walletFile) > 0 {
-			if filepath.IsAbs(walletFile) {
-				walletFilePath = walletFile
-			} else {
-				walletFilePath = configDir + string(os.PathSeparator) + walletFile
-			}
-		} else {
-			walletFilePath = configDir + string(os.PathSeparator) + "wallet.json"
-		}
+		return nil
+	}
 
-		if _, err = os.Stat(walletFilePath); os.IsNotExist(err) {
-			wg := &sync.WaitGroup{}
-			statusBar := &ZCNStatus{wg: wg}
-			wg.Add(1)
-			err = zcncore.CreateWallet(statusBar)
-			if err == nil {
-				wg.Wait()
-			} else {
-				fmt.Println(err.Error())
-				os.Exit(1)
-			}
-			if len(statusBar.walletString) == 0 || !statusBar.success {
-				fmt.Println("Error creating the wallet." + statusBar.errMsg)
-				os.Exit(1)
-			}
-			fmt.Println("ZCN wallet created")
-			walletJSON = string(statusBar.walletString)
-			file, err := os.Create(walletFilePath)
-			if err != nil {
-				fmt.Println(err.Error())
-				os.Exit(1)
-			}
-			defer file.Close()
-			fmt.Fprintf(file, walletJSON)
-
-			fresh = true
-		} else {
-			f, err := os.Open(walletFilePath)
-			if err != nil {
-				fmt.Println("Error opening the wallet", err)
-				os.Exit(1)
-			}
-			clientBytes, err := ioutil.ReadAll(f)
-			if err != nil {
-				fmt.Println("Error reading the wallet", err)
-				os.Exit(1)
-			}
-			walletJSON = string(clientBytes)
-		}
-		//minerjson, _ := json.Marshal(miners)
-		//sharderjson, _ := json.Marshal(sharders)
-		err = json.Unmarshal([]byte(walletJSON), wallet)
-		clientWallet = wallet
-		if err != nil {
-			fmt.Println("Invalid wallet at path:" + walletFilePath)
-			os.Exit(1)
-		}
+	walletFilePath := getWalletFilePath(configDir)
+	if _, err = os.Stat(walletFilePath); os.IsNotExist(err) {
+		return configError("No wallet at " + walletFilePath + ", create one with zbox wallet create or recover one with zbox wallet recover")
 	}
 
+	// an encrypted wallet is only decrypted in memory
+	walletJSON, err = readWalletFile(walletFilePath)
+	if err != nil {
+		return configError("Error reading the wallet", err)
+	}
+	err = json.Unmarshal([]byte(walletJSON), wallet)
+	clientWallet = wallet
+	if err != nil {
+		return configError("Invalid wallet at path:" + walletFilePath)
+	}
+	return nil
+}
+
+// initStorageSDK initializes the storage sdk with walletJSON, once the core sdk is initialized
+func initStorageSDK(cfg conf.Config, network conf.Network) error {
 	//init the storage sdk with the known miners, sharders and client wallet info
-	err = sdk.InitStorageSDK(walletJSON, cfg.BlockWorker, cfg.ChainID, cfg.SignatureScheme, cfg.PreferredBlobbers)
+	err := sdk.InitStorageSDK(walletJSON, cfg.BlockWorker, cfg.ChainID, cfg.SignatureScheme, cfg.PreferredBlobbers)
 	if err != nil {
-		fmt.Println("Error in sdk init", err)
-		os.Exit(1)
+		return configError("Error in sdk init", err)
 	}
 
 	// additional settings depending network latency
@@ -205,13 +301,5 @@ func initConfig() {
 	}
 
 	sdk.SetNumBlockDownloads(10)
-
-	if fresh {
-		fmt.Println("Creating related read pool for storage smart-contract...")
-		if err = sdk.CreateReadPool(); err != nil {
-			fmt.Printf("Failed to create read pool: %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Println("Read pool created successfully")
-	}
+	return nil
 }
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/0chain/gosdk/zboxcore/fileref"
@@ -16,28 +15,24 @@ var shareCmd = &cobra.Command{
 	Short: "share files from blobbers",
 	Long:  `share files from blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags()                      // fflags is a *flag.FlagSet
 		if fflags.Changed("allocation") == false { // check if the flag "path" is set
-			PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-			os.Exit(1)                                      // and os.Exit(1)
+			return usageError("Error: allocation flag is missing")
 		}
 		if fflags.Changed("remotepath") == false {
-			PrintError("Error: remotepath flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath flag is missing")
 		}
 		allocationID := cmd.Flag("allocation").Value.String()
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation", err)
 		}
 		remotepath := cmd.Flag("remotepath").Value.String()
 		refType := fileref.FILE
 		statsMap, err := allocationObj.GetFileStats(remotepath)
 		if err != nil {
-			PrintError("Error in getting information about the object." + err.Error())
-			os.Exit(1)
+			return commandError("Error in getting information about the object.", err)
 		}
 		isFile := false
 		for _, v := range statsMap {
@@ -57,8 +52,7 @@ var shareCmd = &cobra.Command{
 		if revoke {
 			err := allocationObj.RevokeShare(remotepath, refereeClientID)
 			if err != nil {
-				PrintError(err.Error())
-				os.Exit(1)
+				return commandError(err)
 			}
 			fmt.Println("Share revoked for client " + refereeClientID)
 		} else {
@@ -66,11 +60,11 @@ var shareCmd = &cobra.Command{
 			encryptionpublickey := cmd.Flag("encryptionpublickey").Value.String()
 			ref, err := allocationObj.GetAuthTicket(remotepath, fileName, refType, refereeClientID, encryptionpublickey, expiration)
 			if err != nil {
-				PrintError(err.Error())
-				os.Exit(1)
+				return commandError(err)
 			}
 			fmt.Println("Auth token :" + ref)
 		}
+		return nil
 	},
 }
 
@@ -0,0 +1,647 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/0chain/gosdk/zboxcore/fileref"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/0chain/zboxcli/util"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// zboxShell runs commands on one allocation, relative to a remote working directory
+type zboxShell struct {
+	alloc *sdk.Allocation
+	cwd   string
+
+	// term edits lines with history and completion, nil if stdin is not a terminal
+	term *term.Terminal
+
+	// dirs caches the listings of remote directories for completion, it is cleared by commands changing the allocation
+	dirs map[string]*sdk.ListResult
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// shellCommand is a command of zbox shell. Its arguments are completed as remote paths, except those in localArgs.
+type shellCommand struct {
+	usage     string
+	help      string
+	localArgs []int
+	run       func(sh *zboxShell, ctx context.Context, args []string) error
+}
+
+var shellCommands map[string]*shellCommand
+
+func init() {
+	// set in init, as the help command lists shellCommands
+	shellCommands = map[string]*shellCommand{
+		"cd":    {usage: "cd [dir]", help: "change the working directory, / if dir is not given", run: (*zboxShell).cd},
+		"ls":    {usage: "ls [-l] [path]", help: "list a directory, with the details of each file with -l", run: (*zboxShell).ls},
+		"pwd":   {usage: "pwd", help: "print the working directory", run: (*zboxShell).pwd},
+		"get":   {usage: "get <remote> [local]", help: "download a file, to the current local directory if local is not given", localArgs: []int{1}, run: (*zboxShell).get},
+		"put":   {usage: "put <local> [remote]", help: "upload a file, to the working directory if remote is not given", localArgs: []int{0}, run: (*zboxShell).put},
+		"rm":    {usage: "rm <path>", help: "delete a file or directory", run: (*zboxShell).rm},
+		"mv":    {usage: "mv <path> <dir>", help: "move a file or directory to another directory", run: (*zboxShell).mv},
+		"cp":    {usage: "cp <path> <dir>", help: "copy a file or directory to another directory", run: (*zboxShell).cp},
+		"mkdir": {usage: "mkdir <dir>", help: "create a directory", run: (*zboxShell).mkdir},
+		"meta":  {usage: "meta <path>", help: "print the metadata of a file or directory", run: (*zboxShell).meta},
+		"share": {usage: "share <path> [clientid [encryptionpublickey]]", help: "print an auth ticket of a file or directory, public if clientid is not given", run: (*zboxShell).share},
+		"help":  {usage: "help", help: "print this help", run: (*zboxShell).help},
+		"exit":  {usage: "exit", help: "leave the shell, also Ctrl+D"},
+	}
+}
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Interactive shell on an allocation",
+	Long: `Interactive shell on an allocation, with cd, ls, pwd, get, put, rm, mv, cp, mkdir, meta and share
+relative to a remote working directory. The sdk is initialized and the allocation fetched once for all commands.
+Lines are edited with history (up and down keys) and tab completion of commands and paths.
+Ctrl+C stops the running command, Ctrl+D or exit leaves the shell.
+Commands are read one per line without a prompt when stdin is not a terminal.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		allocationID := cmd.Flag("allocation").Value.String()
+		if len(allocationID) == 0 {
+			return usageError("Error: allocation flag is missing")
+		}
+		allocationObj, err := sdk.GetAllocation(allocationID)
+		if err != nil {
+			return commandError("Error fetching the allocation", err)
+		}
+
+		sh := &zboxShell{alloc: allocationObj, cwd: "/", dirs: make(map[string]*sdk.ListResult)}
+		return sh.run(cmd.Context())
+	},
+}
+
+// run reads and runs commands until exit, end of input or ctx is canceled by SIGTERM
+func (sh *zboxShell) run(ctx context.Context) error {
+	// Ctrl+C stops the running command only, not the shell, so the shell takes SIGINT over from withSignalContext
+	signal.Reset(os.Interrupt)
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+	go func() {
+		for range sigs {
+			sh.mu.Lock()
+			if sh.cancel != nil {
+				sh.cancel()
+			}
+			sh.mu.Unlock()
+		}
+	}()
+
+	readLine := sh.lineReader()
+	for {
+		line, err := readLine()
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return commandError("Error reading the command:", err)
+		}
+
+		args, err := splitShellLine(line)
+		if err != nil {
+			PrintError("Error:", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "exit" || args[0] == "quit" {
+			return nil
+		}
+		c, ok := shellCommands[args[0]]
+		if !ok {
+			PrintError("Error: unknown command " + args[0] + ", see help")
+			continue
+		}
+
+		cmdCtx, cancel := context.WithCancel(ctx)
+		sh.mu.Lock()
+		sh.cancel = cancel
+		sh.mu.Unlock()
+		err = c.run(sh, cmdCtx, args[1:])
+		sh.mu.Lock()
+		sh.cancel = nil
+		sh.mu.Unlock()
+		if err != nil && cmdCtx.Err() != nil && ctx.Err() == nil {
+			err = errors.New("interrupted")
+		}
+		cancel()
+
+		if err != nil {
+			PrintError("Error:", err)
+		}
+		if err := interruptedError(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// lineReader returns a func reading the next command, edited in raw mode with history and completion on a terminal
+func (sh *zboxShell) lineReader() func() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		scanner := bufio.NewScanner(os.Stdin)
+		return func() (string, error) {
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return "", err
+				}
+				return "", io.EOF
+			}
+			return scanner.Text(), nil
+		}
+	}
+
+	sh.term = term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{ctrlCReader{os.Stdin}, os.Stdout}, "")
+	sh.term.AutoCompleteCallback = sh.complete
+	return func() (string, error) {
+		// the terminal is raw while a line is edited only, so Ctrl+C interrupts commands as usual
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return "", err
+		}
+		defer term.Restore(fd, state)
+		sh.term.SetPrompt(sh.alloc.ID[:8] + ":" + sh.cwd + "> ")
+		return sh.term.ReadLine()
+	}
+}
+
+// ctrlCReader turns Ctrl+C into Ctrl+U, which clears the edited line instead of ending the input
+type ctrlCReader struct {
+	r io.Reader
+}
+
+func (r ctrlCReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	for i := 0; i < n; i++ {
+		if p[i] == 3 {
+			p[i] = 21
+		}
+	}
+	return n, err
+}
+
+// splitShellLine splits line into arguments separated by spaces, quoted by ' or ", or with spaces escaped by \
+func splitShellLine(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// remotePath returns p as an absolute remote path, relative to the working directory if it is not absolute
+func (sh *zboxShell) remotePath(p string) string {
+	if strings.HasPrefix(p, "/") {
+		return path.Clean(p)
+	}
+	return path.Join(sh.cwd, p)
+}
+
+// listDir lists the remote directory dir, from the cache of listings if it was listed since the last change
+func (sh *zboxShell) listDir(dir string) (*sdk.ListResult, error) {
+	if ref, ok := sh.dirs[dir]; ok {
+		return ref, nil
+	}
+	ref, err := sh.alloc.ListDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sh.dirs[dir] = ref
+	return ref, nil
+}
+
+// changed clears the cache of listings after the allocation was changed
+func (sh *zboxShell) changed() {
+	sh.dirs = make(map[string]*sdk.ListResult)
+}
+
+func (sh *zboxShell) isDir(p string) bool {
+	ref, err := sh.alloc.ListDir(p)
+	return err == nil && ref.Type == fileref.DIRECTORY
+}
+
+func checkShellArgs(args []string, min, max int, usage string) error {
+	if len(args) < min || len(args) > max {
+		return errors.New("usage: " + usage)
+	}
+	return nil
+}
+
+func (sh *zboxShell) cd(ctx context.Context, args []string) error {
+	if err := checkShellArgs(args, 0, 1, shellCommands["cd"].usage); err != nil {
+		return err
+	}
+	dir := "/"
+	if len(args) > 0 {
+		dir = sh.remotePath(args[0])
+	}
+	ref, err := sh.alloc.ListDir(dir)
+	if err != nil {
+		return err
+	}
+	if ref.Type != fileref.DIRECTORY {
+		return errors.New(dir + " is not a directory")
+	}
+	sh.dirs[dir] = ref
+	sh.cwd = dir
+	return nil
+}
+
+func (sh *zboxShell) ls(ctx context.Context, args []string) error {
+	long := len(args) > 0 && args[0] == "-l"
+	if long {
+		args = args[1:]
+	}
+	if err := checkShellArgs(args, 0, 1, shellCommands["ls"].usage); err != nil {
+		return err
+	}
+	dir := sh.cwd
+	if len(args) > 0 {
+		dir = sh.remotePath(args[0])
+	}
+	ref, err := sh.alloc.ListDir(dir)
+	if err != nil {
+		return err
+	}
+	sh.dirs[dir] = ref
+
+	if !long {
+		for _, child := range ref.Children {
+			if child.Type == fileref.DIRECTORY {
+				fmt.Println(child.Name + "/")
+			} else {
+				fmt.Println(child.Name)
+			}
+		}
+		return nil
+	}
+	header := []string{"Type", "Name", "Size", "Num Blocks", "Lookup Hash", "Is Encrypted"}
+	data := make([][]string, len(ref.Children))
+	for idx, child := range ref.Children {
+		size := strconv.FormatInt(child.Size, 10)
+		isEncrypted := ""
+		if child.Type == fileref.DIRECTORY {
+			size = ""
+		} else if len(child.EncryptionKey) > 0 {
+			isEncrypted = "YES"
+		} else {
+			isEncrypted = "NO"
+		}
+		data[idx] = []string{child.Type, child.Name, size, strconv.FormatInt(child.NumBlocks, 10), child.LookupHash, isEncrypted}
+	}
+	util.WriteTable(os.Stdout, header, []string{}, data)
+	return nil
+}
+
+func (sh *zboxShell) pwd(ctx context.Context, args []string) error {
+	if err := checkShellArgs(args, 0, 0, shellCommands["pwd"].usage); err != nil {
+		return err
+	}
+	fmt.Println(sh.cwd)
+	return nil
+}
+
+func (sh *zboxShell) get(ctx context.Context, args []string) error {
+	if err := checkShellArgs(args, 1, 2, shellCommands["get"].usage); err != nil {
+		return err
+	}
+	remotePath := sh.remotePath(args[0])
+	localPath := "."
+	if len(args) > 1 {
+		localPath = args[1]
+	}
+
+	wg := &sync.WaitGroup{}
+	statusBar := &StatusBar{wg: wg}
+	wg.Add(1)
+	if err := sh.alloc.DownloadFile(localPath, remotePath, statusBar); err != nil {
+		return err
+	}
+	stop := cancelDownloadOnInterrupt(ctx, sh.alloc, remotePath)
+	wg.Wait()
+	stop()
+	if !statusBar.success {
+		return errors.New("download of " + remotePath + " failed")
+	}
+	return nil
+}
+
+func (sh *zboxShell) put(ctx context.Context, args []string) error {
+	if err := checkShellArgs(args, 1, 2, shellCommands["put"].usage); err != nil {
+		return err
+	}
+	localPath := args[0]
+	remotePath := sh.cwd + "/"
+	if len(args) > 1 {
+		remotePath = sh.remotePath(args[1])
+		if strings.HasSuffix(args[1], "/") || sh.isDir(remotePath) {
+			remotePath += "/"
+		}
+	}
+	encrypt := strings.HasPrefix(remotePath, "/Encrypted")
+
+	wg := &sync.WaitGroup{}
+	statusBar := &StatusBar{wg: wg}
+	wg.Add(1)
+	err := startChunkedUpload(ctx, sh.alloc, localPath, "", remotePath, encrypt, sdk.CHUNK_SIZE, fileref.Attributes{}, statusBar, false)
+	sh.changed()
+	if err != nil {
+		return err
+	}
+	wg.Wait()
+	if !statusBar.success {
+		return errors.New("upload of " + localPath + " failed")
+	}
+	return nil
+}
+
+func (sh *zboxShell) rm(ctx context.Context, args []string) error {
+	if err := checkShellArgs(args, 1, 1, shellCommands["rm"].usage); err != nil {
+		return err
+	}
+	remotePath := sh.remotePath(args[0])
+	if remotePath == "/" {
+		return errors.New("the root directory can not be deleted")
+	}
+	err := sh.alloc.DeleteFile(remotePath)
+	sh.changed()
+	if err != nil {
+		return err
+	}
+	fmt.Println(remotePath + " deleted")
+	return nil
+}
+
+func (sh *zboxShell) mv(ctx context.Context, args []string) error {
+	if err := checkShellArgs(args, 2, 2, shellCommands["mv"].usage); err != nil {
+		return err
+	}
+	remotePath, destPath := sh.remotePath(args[0]), sh.remotePath(args[1])
+	err := sh.alloc.MoveObject(remotePath, destPath)
+	sh.changed()
+	if err != nil {
+		return err
+	}
+	fmt.Println(remotePath + " moved")
+	return nil
+}
+
+func (sh *zboxShell) cp(ctx context.Context, args []string) error {
+	if err := checkShellArgs(args, 2, 2, shellCommands["cp"].usage); err != nil {
+		return err
+	}
+	remotePath, destPath := sh.remotePath(args[0]), sh.remotePath(args[1])
+	err := sh.alloc.CopyObject(remotePath, destPath)
+	sh.changed()
+	if err != nil {
+		return err
+	}
+	fmt.Println(remotePath + " copied")
+	return nil
+}
+
+func (sh *zboxShell) mkdir(ctx context.Context, args []string) error {
+	if err := checkShellArgs(args, 1, 1, shellCommands["mkdir"].usage); err != nil {
+		return err
+	}
+	err := sh.alloc.CreateDir(sh.remotePath(args[0]))
+	sh.changed()
+	return err
+}
+
+func (sh *zboxShell) meta(ctx context.Context, args []string) error {
+	if err := checkShellArgs(args, 1, 1, shellCommands["meta"].usage); err != nil {
+		return err
+	}
+	ref, err := sh.alloc.GetFileMeta(sh.remotePath(args[0]))
+	if err != nil {
+		return err
+	}
+	header := []string{"Type", "Name", "Path", "Lookup Hash"}
+	data := []string{ref.Type, ref.Name, ref.Path, ref.LookupHash}
+	if ref.Type == fileref.FILE {
+		header = append(header, "Size", "Mime Type", "Hash")
+		data = append(data, strconv.FormatInt(ref.Size, 10), ref.MimeType, ref.Hash)
+	}
+	util.WriteTable(os.Stdout, header, []string{}, [][]string{data})
+	return nil
+}
+
+func (sh *zboxShell) share(ctx context.Context, args []string) error {
+	if err := checkShellArgs(args, 1, 3, shellCommands["share"].usage); err != nil {
+		return err
+	}
+	remotePath := sh.remotePath(args[0])
+	var clientID, encryptionPublicKey string
+	if len(args) > 1 {
+		clientID = args[1]
+	}
+	if len(args) > 2 {
+		encryptionPublicKey = args[2]
+	}
+
+	refType := fileref.FILE
+	if sh.isDir(remotePath) {
+		refType = fileref.DIRECTORY
+	}
+	ticket, err := sh.alloc.GetAuthTicket(remotePath, path.Base(remotePath), refType, clientID, encryptionPublicKey, 0)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Auth token :" + ticket)
+	return nil
+}
+
+func (sh *zboxShell) help(ctx context.Context, args []string) error {
+	names := make([]string, 0, len(shellCommands))
+	for name := range shellCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	data := make([][]string, 0, len(names))
+	for _, name := range names {
+		data = append(data, []string{shellCommands[name].usage, shellCommands[name].help})
+	}
+	util.WriteTable(os.Stdout, []string{"Command", "Description"}, []string{}, data)
+	return nil
+}
+
+// complete completes the command name or path before the cursor on tab.
+// Candidates are printed if there is more than one and the common prefix does not complete any further.
+func (sh *zboxShell) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	before := line[:pos]
+	start := strings.LastIndexAny(before, " \t") + 1
+	word := before[start:]
+	words := strings.Fields(before[:start])
+
+	var candidates []string
+	var dirPart string
+	if len(words) == 0 {
+		for name := range shellCommands {
+			if strings.HasPrefix(name, word) {
+				candidates = append(candidates, name+" ")
+			}
+		}
+	} else {
+		c, ok := shellCommands[words[0]]
+		if !ok || words[0] == "help" || words[0] == "exit" || words[0] == "pwd" {
+			return "", 0, false
+		}
+		argIndex := len(words) - 1
+		if argIndex > 0 && words[1] == "-l" {
+			argIndex--
+		}
+		if i := strings.LastIndex(word, "/"); i >= 0 {
+			dirPart = word[:i+1]
+		}
+		local := false
+		for _, i := range c.localArgs {
+			local = local || i == argIndex
+		}
+		if local {
+			candidates = completeLocalPath(dirPart, word[len(dirPart):])
+		} else {
+			candidates = sh.completeRemotePath(dirPart, word[len(dirPart):])
+		}
+	}
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+	sort.Strings(candidates)
+
+	completion := candidates[0]
+	for _, c := range candidates[1:] {
+		completion = commonPrefix(completion, c)
+	}
+	completion = dirPart + completion
+	if len(candidates) > 1 && completion == word {
+		sh.term.Write([]byte(strings.Join(candidates, "  ") + "\n"))
+		return "", 0, false
+	}
+	newLine := line[:start] + completion + line[pos:]
+	return newLine, start + len(completion), true
+}
+
+func (sh *zboxShell) completeRemotePath(dirPart, prefix string) []string {
+	dir := sh.cwd
+	if len(dirPart) > 0 {
+		dir = sh.remotePath(dirPart)
+	}
+	ref, err := sh.listDir(dir)
+	if err != nil {
+		return nil
+	}
+	var candidates []string
+	for _, child := range ref.Children {
+		if !strings.HasPrefix(child.Name, prefix) {
+			continue
+		}
+		if child.Type == fileref.DIRECTORY {
+			candidates = append(candidates, child.Name+"/")
+		} else {
+			candidates = append(candidates, child.Name+" ")
+		}
+	}
+	return candidates
+}
+
+func completeLocalPath(dirPart, prefix string) []string {
+	dir := "."
+	if len(dirPart) > 0 {
+		dir = dirPart
+	}
+	infos, err := ioutil.ReadDir(filepath.FromSlash(dir))
+	if err != nil {
+		return nil
+	}
+	var candidates []string
+	for _, info := range infos {
+		if !strings.HasPrefix(info.Name(), prefix) {
+			continue
+		}
+		if info.IsDir() {
+			candidates = append(candidates, info.Name()+"/")
+		} else {
+			candidates = append(candidates, info.Name()+" ")
+		}
+	}
+	return candidates
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+	shellCmd.PersistentFlags().String("allocation", "", "Allocation ID")
+	shellCmd.MarkFlagRequired("allocation")
+}
@@ -2,12 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"strconv"
 
 	"github.com/0chain/gosdk/core/common"
 	"github.com/0chain/gosdk/zboxcore/sdk"
 	"github.com/0chain/gosdk/zcncore"
-	"github.com/0chain/zboxcli/util"
 
 	"github.com/spf13/cobra"
 )
@@ -92,13 +91,29 @@ func printStakePoolUserInfo(info *sdk.StakePoolUserInfo) {
 	}
 }
 
+// delegatePoolCSVHeader is the csv header of sp-info and sp-user-info, a row for each delegate pool
+var delegatePoolCSVHeader = []string{"blobber_id", "id", "balance", "delegate_id", "rewards", "interests", "penalty", "pending_interests", "unstake"}
+
+func delegatePoolCSVRow(blobberID common.Key, dp *sdk.StakePoolDelegatePoolInfo) []string {
+	return []string{
+		string(blobberID), string(dp.ID),
+		strconv.FormatInt(int64(dp.Balance), 10),
+		string(dp.DelegateID),
+		strconv.FormatInt(int64(dp.Rewards), 10),
+		strconv.FormatInt(int64(dp.Interests), 10),
+		strconv.FormatInt(int64(dp.Penalty), 10),
+		strconv.FormatInt(int64(dp.PendingInterests), 10),
+		strconv.FormatInt(int64(dp.Unstake), 10),
+	}
+}
+
 // spInfo information
 var spInfo = &cobra.Command{
 	Use:   "sp-info",
 	Short: "Stake pool information.",
 	Long:  `Stake pool information.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags     = cmd.Flags()
@@ -106,23 +121,24 @@ var spInfo = &cobra.Command{
 			err       error
 		)
 
-		doJSON, _ := cmd.Flags().GetBool("json")
-
 		if flags.Changed("blobber_id") {
 			if blobberID, err = flags.GetString("blobber_id"); err != nil {
-				log.Fatalf("can't get 'blobber_id' flag: %v", err)
+				return usageError("can't get 'blobber_id' flag:", err)
 			}
 		}
 
 		var info *sdk.StakePoolInfo
 		if info, err = sdk.GetStakePoolInfo(blobberID); err != nil {
-			log.Fatalf("Failed to get stake pool info: %v", err)
+			return commandError("Failed to get stake pool info:", err)
 		}
-		if doJSON {
-			util.PrintJSON(info)
-		} else {
-			printStakePoolInfo(info)
+		data := make([][]string, len(info.Delegate))
+		for idx, dp := range info.Delegate {
+			data[idx] = delegatePoolCSVRow(info.ID, dp)
 		}
+		printOutput(cmd, info, delegatePoolCSVHeader, data, func() {
+			printStakePoolInfo(info)
+		})
+		return nil
 	},
 }
 
@@ -132,7 +148,7 @@ var spUserInfo = &cobra.Command{
 	Short: "Stake pool information for a user.",
 	Long:  `Stake pool information for a user.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags    = cmd.Flags()
@@ -140,23 +156,26 @@ var spUserInfo = &cobra.Command{
 			err      error
 		)
 
-		doJSON, _ := cmd.Flags().GetBool("json")
-
 		if flags.Changed("client_id") {
 			if clientID, err = flags.GetString("client_id"); err != nil {
-				log.Fatalf("can't get 'client_id' flag: %v", err)
+				return usageError("can't get 'client_id' flag:", err)
 			}
 		}
 
 		var info *sdk.StakePoolUserInfo
 		if info, err = sdk.GetStakePoolUserInfo(clientID); err != nil {
-			log.Fatalf("Failed to get stake pool info: %v", err)
+			return commandError("Failed to get stake pool info:", err)
 		}
-		if doJSON {
-			util.PrintJSON(info)
-		} else {
-			printStakePoolUserInfo(info)
+		var data [][]string
+		for blobberID, dps := range info.Pools {
+			for _, dp := range dps {
+				data = append(data, delegatePoolCSVRow(blobberID, dp))
+			}
 		}
+		printOutput(cmd, info, delegatePoolCSVHeader, data, func() {
+			printStakePoolUserInfo(info)
+		})
+		return nil
 	},
 }
 
@@ -166,7 +185,7 @@ var spLock = &cobra.Command{
 	Short: "Lock tokens lacking in stake pool.",
 	Long:  `Lock tokens lacking in stake pool.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags     = cmd.Flags()
@@ -178,21 +197,21 @@ var spLock = &cobra.Command{
 
 		if flags.Changed("blobber_id") {
 			if blobberID, err = flags.GetString("blobber_id"); err != nil {
-				log.Fatalf("invalid 'blobber_id' flag: %v", err)
+				return usageError("invalid 'blobber_id' flag:", err)
 			}
 		}
 
 		if !flags.Changed("tokens") {
-			log.Fatal("missing required 'tokens' flag")
+			return usageError("missing required 'tokens' flag")
 		}
 
 		if tokens, err = flags.GetFloat64("tokens"); err != nil {
-			log.Fatal("invalid 'tokens' flag: ", err)
+			return usageError("invalid 'tokens' flag: ", err)
 		}
 
 		if flags.Changed("fee") {
 			if fee, err = flags.GetFloat64("fee"); err != nil {
-				log.Fatal("invalid 'fee' flag: ", err)
+				return usageError("invalid 'fee' flag: ", err)
 			}
 		}
 
@@ -200,9 +219,10 @@ var spLock = &cobra.Command{
 		poolID, err = sdk.StakePoolLock(blobberID,
 			zcncore.ConvertToValue(tokens), zcncore.ConvertToValue(fee))
 		if err != nil {
-			log.Fatalf("Failed to lock tokens in stake pool: %v", err)
+			return commandError("Failed to lock tokens in stake pool:", err)
 		}
 		fmt.Println("tokens locked, pool id:", poolID)
+		return nil
 	},
 }
 
@@ -212,7 +232,7 @@ var spUnlock = &cobra.Command{
 	Short: "Unlock tokens in stake pool.",
 	Long:  `Unlock tokens in stake pool.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags             = cmd.Flags()
@@ -223,21 +243,21 @@ var spUnlock = &cobra.Command{
 
 		if flags.Changed("blobber_id") {
 			if blobberID, err = flags.GetString("blobber_id"); err != nil {
-				log.Fatalf("invalid 'blobber_id' flag: %v", err)
+				return usageError("invalid 'blobber_id' flag:", err)
 			}
 		}
 
 		if !flags.Changed("pool_id") {
-			log.Fatal("missing required 'pool_id' flag")
+			return usageError("missing required 'pool_id' flag")
 		}
 
 		if poolID, err = flags.GetString("pool_id"); err != nil {
-			log.Fatal("invalid 'pool_id' flag: ", err)
+			return usageError("invalid 'pool_id' flag: ", err)
 		}
 
 		if flags.Changed("fee") {
 			if fee, err = flags.GetFloat64("fee"); err != nil {
-				log.Fatal("invalid 'fee' flag: ", err)
+				return usageError("invalid 'fee' flag: ", err)
 			}
 		}
 
@@ -247,7 +267,7 @@ var spUnlock = &cobra.Command{
 
 		// an error
 		if err != nil {
-			log.Fatalf("Failed to unlock tokens in stake pool: %v", err)
+			return commandError("Failed to unlock tokens in stake pool:", err)
 		}
 
 		// can't unlock for now
@@ -255,11 +275,12 @@ var spUnlock = &cobra.Command{
 			fmt.Println("tokens can't be unlocked due to opened offers")
 			fmt.Printf("the pool marked as releasing, wait %s and retry to succeed", unstake.ToTime())
 			fmt.Println()
-			return
+			return nil
 		}
 
 		// success
 		fmt.Println("tokens has unlocked, pool deleted")
+		return nil
 	},
 }
 
@@ -271,7 +292,7 @@ var spPayInterests = &cobra.Command{
 	Short: "Pay interests not payed yet.",
 	Long:  `Pay interests not payed.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags     = cmd.Flags()
@@ -281,14 +302,15 @@ var spPayInterests = &cobra.Command{
 
 		if flags.Changed("blobber_id") {
 			if blobberID, err = flags.GetString("blobber_id"); err != nil {
-				log.Fatalf("invalid 'blobber_id' flag: %v", err)
+				return usageError("invalid 'blobber_id' flag:", err)
 			}
 		}
 
 		if err = sdk.StakePoolPayInterests(blobberID); err != nil {
-			log.Fatalf("Failed to pay interests: %v", err)
+			return commandError("Failed to pay interests:", err)
 		}
 		fmt.Println("interests has payed")
+		return nil
 	},
 }
 
@@ -301,9 +323,9 @@ func init() {
 
 	spInfo.PersistentFlags().String("blobber_id", "",
 		"for given blobber, default is current client")
-	spInfo.PersistentFlags().Bool("json", false, "pass this option to print response as json data")
+	spInfo.PersistentFlags().Bool("json", false, "pass this option to print response as json data, same as --output json")
 
-	spUserInfo.PersistentFlags().Bool("json", false, "pass this option to print response as json data")
+	spUserInfo.PersistentFlags().Bool("json", false, "pass this option to print response as json data, same as --output json")
 
 	spLock.PersistentFlags().String("blobber_id", "",
 		"for given blobber, default is current client")
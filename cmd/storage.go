@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"os"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/0chain/gosdk/core/common"
@@ -53,18 +55,26 @@ var scConfig = &cobra.Command{
 	Short: "Show storage SC configuration.",
 	Long:  `Show storage SC configuration.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
-		doJSON, _ := cmd.Flags().GetBool("json")
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var conf, err = sdk.GetStorageSCConfig()
 		if err != nil {
-			log.Fatalf("Failed to get storage SC configurations: %v", err)
+			return commandError("Failed to get storage SC configurations:", err)
 		}
-		if doJSON {
-			util.PrintJSON(conf)
-			return
+
+		keys := make([]string, 0, len(conf.Fields))
+		for k := range conf.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		data := make([][]string, len(keys))
+		for idx, k := range keys {
+			data[idx] = []string{k, fmt.Sprint(conf.Fields[k])}
 		}
-		util.PrintJSON(conf.Fields)
+		printOutput(cmd, conf, []string{"key", "value"}, data, func() {
+			util.WriteTable(os.Stdout, []string{"Key", "Value"}, []string{}, data)
+		})
 		// printStorageSCConfig(conf)
+		return nil
 	},
 }
 
@@ -88,6 +98,24 @@ func printBlobbers(nodes []*sdk.Blobber) {
 	}
 }
 
+// blobberCSVHeader is the csv header of ls-blobbers and bl-info, see blobberCSVRow
+var blobberCSVHeader = []string{"id", "url", "used", "capacity", "last_health_check", "read_price", "write_price",
+	"min_lock_demand", "max_offer_duration", "challenge_completion_time"}
+
+func blobberCSVRow(b *sdk.Blobber) []string {
+	return []string{
+		string(b.ID), b.BaseURL,
+		strconv.FormatInt(int64(b.Used), 10),
+		strconv.FormatInt(int64(b.Capacity), 10),
+		strconv.FormatInt(int64(b.LastHealthCheck), 10),
+		strconv.FormatInt(int64(b.Terms.ReadPrice), 10),
+		strconv.FormatInt(int64(b.Terms.WritePrice), 10),
+		strconv.FormatFloat(b.Terms.MinLockDemand, 'f', -1, 64),
+		strconv.FormatInt(int64(b.Terms.MaxOfferDuration), 10),
+		strconv.FormatInt(int64(b.Terms.ChallengeCompletionTime), 10),
+	}
+}
+
 func filterActiveBlobbers(blobbers []*sdk.Blobber) (activeBlobbers []*sdk.Blobber) {
 	for i := range blobbers {
 		if blobbers[i].LastHealthCheck.Within(60 * 60) {
@@ -103,27 +131,29 @@ var lsBlobers = &cobra.Command{
 	Short: "Show active blobbers in storage SC.",
 	Long:  `Show active blobbers in storage SC.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
-		doJSON, _ := cmd.Flags().GetBool("json")
+	RunE: func(cmd *cobra.Command, args []string) error {
 		doAll, _ := cmd.Flags().GetBool("all")
 
 		var list, err = sdk.GetBlobbers()
 		var defaultList = filterActiveBlobbers(list)
 
 		if err != nil {
-			log.Fatalf("Failed to get storage SC configurations: %v", err)
+			return commandError("Failed to get storage SC configurations:", err)
 		}
 
 		if doAll {
 			defaultList = list
 		}
 
-		if doJSON {
-			util.PrintJSON(defaultList)
-		} else {
-			printBlobbers(defaultList)
+		data := make([][]string, len(defaultList))
+		for idx, b := range defaultList {
+			data[idx] = blobberCSVRow(b)
 		}
+		printOutput(cmd, defaultList, blobberCSVHeader, data, func() {
+			printBlobbers(defaultList)
+		})
 
+		return nil
 	},
 }
 
@@ -132,66 +162,62 @@ var blobberInfoCmd = &cobra.Command{
 	Short: "Get blobber info",
 	Long:  `Get blobber info`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags = cmd.Flags()
 
-			json      bool
 			blobberID string
 			err       error
 		)
 
-		if flags.Changed("json") {
-			if json, err = flags.GetBool("json"); err != nil {
-				log.Fatal("invalid 'json' flag: ", err)
-			}
-		}
-
 		if !flags.Changed("blobber_id") {
-			log.Fatal("missing required 'blobber_id' flag")
+			return usageError("missing required 'blobber_id' flag")
 		}
 
 		if blobberID, err = flags.GetString("blobber_id"); err != nil {
-			log.Fatal("error in 'blobber_id' flag: ", err)
+			return usageError("error in 'blobber_id' flag: ", err)
 		}
 
 		var blob *sdk.Blobber
 		if blob, err = sdk.GetBlobber(blobberID); err != nil {
-			log.Fatal(err)
+			return commandError(err)
 		}
 
-		if json {
-			util.PrintJSON(blob)
-			return
-		}
-
-		fmt.Println("id:               ", blob.ID)
-		fmt.Println("url:              ", blob.BaseURL)
-		fmt.Println("capacity:         ", blob.Capacity)
-		fmt.Println("last_health_check:", blob.LastHealthCheck.ToTime())
-		fmt.Println("capacity_used:    ", blob.Used)
-		fmt.Println("terms:")
-		fmt.Println("  read_price:        ", blob.Terms.ReadPrice, "/ GB")
-		fmt.Println("  write_price:       ", blob.Terms.WritePrice, "/ GB")
-		fmt.Println("  min_lock_demand:   ", blob.Terms.MinLockDemand*100.0, "%")
-		fmt.Println("  max_offer_duration:", blob.Terms.MaxOfferDuration)
-		fmt.Println("  cct:               ", blob.Terms.ChallengeCompletionTime)
-		fmt.Println("settings:")
-		fmt.Println("  delegate_wallet:", blob.StakePoolSettings.DelegateWallet)
-		fmt.Println("  min_stake:      ", blob.StakePoolSettings.MinStake)
-		fmt.Println("  max_stake:      ", blob.StakePoolSettings.MaxStake)
-		fmt.Println("  num_delegates:  ", blob.StakePoolSettings.NumDelegates)
-		fmt.Println("  service_charge: ", blob.StakePoolSettings.ServiceCharge*100, "%")
+		printOutput(cmd, blob, blobberCSVHeader, [][]string{blobberCSVRow(blob)}, func() {
+			printBlobber(blob)
+		})
+		return nil
 	},
 }
 
+// printBlobber prints blob for table output of bl-info
+func printBlobber(blob *sdk.Blobber) {
+	fmt.Println("id:               ", blob.ID)
+	fmt.Println("url:              ", blob.BaseURL)
+	fmt.Println("capacity:         ", blob.Capacity)
+	fmt.Println("last_health_check:", blob.LastHealthCheck.ToTime())
+	fmt.Println("capacity_used:    ", blob.Used)
+	fmt.Println("terms:")
+	fmt.Println("  read_price:        ", blob.Terms.ReadPrice, "/ GB")
+	fmt.Println("  write_price:       ", blob.Terms.WritePrice, "/ GB")
+	fmt.Println("  min_lock_demand:   ", blob.Terms.MinLockDemand*100.0, "%")
+	fmt.Println("  max_offer_duration:", blob.Terms.MaxOfferDuration)
+	fmt.Println("  cct:               ", blob.Terms.ChallengeCompletionTime)
+	fmt.Println("settings:")
+	fmt.Println("  delegate_wallet:", blob.StakePoolSettings.DelegateWallet)
+	fmt.Println("  min_stake:      ", blob.StakePoolSettings.MinStake)
+	fmt.Println("  max_stake:      ", blob.StakePoolSettings.MaxStake)
+	fmt.Println("  num_delegates:  ", blob.StakePoolSettings.NumDelegates)
+	fmt.Println("  service_charge: ", blob.StakePoolSettings.ServiceCharge*100, "%")
+}
+
 var blobberUpdateCmd = &cobra.Command{
 	Use:   "bl-update",
 	Short: "Update blobber settings by its delegate_wallet owner",
 	Long:  `Update blobber settings by its delegate_wallet owner`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var (
 			flags = cmd.Flags()
 
@@ -200,22 +226,22 @@ var blobberUpdateCmd = &cobra.Command{
 		)
 
 		if !flags.Changed("blobber_id") {
-			log.Fatal("missing required 'blobber_id' flag")
+			return usageError("missing required 'blobber_id' flag")
 		}
 
 		if blobberID, err = flags.GetString("blobber_id"); err != nil {
-			log.Fatal("error in 'blobber_id' flag: ", err)
+			return usageError("error in 'blobber_id' flag: ", err)
 		}
 
 		var blob *sdk.Blobber
 		if blob, err = sdk.GetBlobber(blobberID); err != nil {
-			log.Fatal(err)
+			return commandError(err)
 		}
 
 		if flags.Changed("capacity") {
 			var capacity int64
 			if capacity, err = flags.GetInt64("capacity"); err != nil {
-				log.Fatal(err)
+				return commandError(err)
 			}
 			blob.Capacity = common.Size(capacity)
 		}
@@ -223,7 +249,7 @@ var blobberUpdateCmd = &cobra.Command{
 		if flags.Changed("read_price") {
 			var rp float64
 			if rp, err = flags.GetFloat64("read_price"); err != nil {
-				log.Fatal(err)
+				return commandError(err)
 			}
 			blob.Terms.ReadPrice = common.ToBalance(rp)
 		}
@@ -231,7 +257,7 @@ var blobberUpdateCmd = &cobra.Command{
 		if flags.Changed("write_price") {
 			var wp float64
 			if wp, err = flags.GetFloat64("write_price"); err != nil {
-				log.Fatal(err)
+				return commandError(err)
 			}
 			blob.Terms.WritePrice = common.ToBalance(wp)
 		}
@@ -239,10 +265,10 @@ var blobberUpdateCmd = &cobra.Command{
 		if flags.Changed("min_lock_demand") {
 			var mld float64
 			if mld, err = flags.GetFloat64("min_lock_demand"); err != nil {
-				log.Fatal(err)
+				return commandError(err)
 			}
 			if mld < 0 || mld > 1 {
-				log.Fatal("invalid min_lock_demand: out of [0; 1) range")
+				return usageError("invalid min_lock_demand: out of [0; 1) range")
 			}
 			blob.Terms.MinLockDemand = mld
 		}
@@ -250,7 +276,7 @@ var blobberUpdateCmd = &cobra.Command{
 		if flags.Changed("max_offer_duration") {
 			var mod time.Duration
 			if mod, err = flags.GetDuration("max_offer_duration"); err != nil {
-				log.Fatal(err)
+				return commandError(err)
 			}
 			blob.Terms.MaxOfferDuration = mod
 		}
@@ -258,7 +284,7 @@ var blobberUpdateCmd = &cobra.Command{
 		if flags.Changed("cct") {
 			var cct time.Duration
 			if cct, err = flags.GetDuration("cct"); err != nil {
-				log.Fatal(err)
+				return commandError(err)
 			}
 			blob.Terms.ChallengeCompletionTime = cct
 		}
@@ -266,7 +292,7 @@ var blobberUpdateCmd = &cobra.Command{
 		if flags.Changed("min_stake") {
 			var minStake float64
 			if minStake, err = flags.GetFloat64("min_stake"); err != nil {
-				log.Fatal(err)
+				return commandError(err)
 			}
 			blob.StakePoolSettings.MinStake = common.ToBalance(minStake)
 		}
@@ -274,7 +300,7 @@ var blobberUpdateCmd = &cobra.Command{
 		if flags.Changed("max_stake") {
 			var maxStake float64
 			if maxStake, err = flags.GetFloat64("max_stake"); err != nil {
-				log.Fatal(err)
+				return commandError(err)
 			}
 			blob.StakePoolSettings.MaxStake = common.ToBalance(maxStake)
 		}
@@ -282,7 +308,7 @@ var blobberUpdateCmd = &cobra.Command{
 		if flags.Changed("num_delegates") {
 			var nd int
 			if nd, err = flags.GetInt("num_delegates"); err != nil {
-				log.Fatal(err)
+				return commandError(err)
 			}
 			blob.StakePoolSettings.NumDelegates = nd
 		}
@@ -290,17 +316,18 @@ var blobberUpdateCmd = &cobra.Command{
 		if flags.Changed("service_charge") {
 			var sc float64
 			if sc, err = flags.GetFloat64("service_charge"); err != nil {
-				log.Fatal(err)
+				return commandError(err)
 			}
 			blob.StakePoolSettings.ServiceCharge = sc
 		}
 
 		if _, err = sdk.UpdateBlobberSettings(blob); err != nil {
-			log.Fatal(err)
+			return commandError(err)
 		}
 
 		fmt.Println("blobber settings updated successfully")
 
+		return nil
 	},
 }
 
@@ -310,13 +337,13 @@ func init() {
 	rootCmd.AddCommand(blobberInfoCmd)
 	rootCmd.AddCommand(blobberUpdateCmd)
 
-	scConfig.Flags().Bool("json", false, "pass this option to print response as json data")
-	lsBlobers.Flags().Bool("json", false, "pass this option to print response as json data")
+	scConfig.Flags().Bool("json", false, "pass this option to print response as json data, same as --output json")
+	lsBlobers.Flags().Bool("json", false, "pass this option to print response as json data, same as --output json")
 	lsBlobers.Flags().Bool("all", false, "shows active and non active list of blobbers on ls-blobbers")
 
 	blobberInfoCmd.Flags().String("blobber_id", "", "blobber ID, required")
 	blobberInfoCmd.Flags().Bool("json", false,
-		"pass this option to print response as json data")
+		"pass this option to print response as json data, same as --output json")
 	blobberInfoCmd.MarkFlagRequired("blobber_id")
 
 	buf := blobberUpdateCmd.Flags()
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/0chain/gosdk/zboxcore/fileref"
 	"github.com/0chain/gosdk/zboxcore/sdk"
@@ -22,15 +25,74 @@ func printTable(files []sdk.FileDiff) {
 	fmt.Println("")
 }
 
-func saveCache(allocationObj *sdk.Allocation, path string, exclPath []string) {
-	if len(path) > 0 {
-		err := allocationObj.SaveRemoteSnapshot(path, exclPath)
+// syncCache is the local cache of sync, the snapshot of the remote after the previous sync
+type syncCache struct {
+	path string
+	prev map[string]json.RawMessage // entries of the previous snapshot, by path
+	keep []string                   // paths whose previous entries are copied to the new snapshot
+}
+
+// loadSyncCache reads the previous snapshot at path, a missing file is an empty snapshot
+func loadSyncCache(path string) (*syncCache, error) {
+	c := &syncCache{path: path, prev: make(map[string]json.RawMessage)}
+	if len(path) == 0 {
+		return c, nil
+	}
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, &c.prev); err != nil {
+		return nil, fmt.Errorf("invalid cache content: %v", err)
+	}
+	return c, nil
+}
+
+// keepEntries copies the previous entries of paths and of the files under them to the new snapshot.
+// A local deletion which is skipped keeps the remote file it was planned for in the snapshot,
+// so the next sync plans it again instead of uploading the local file.
+func (c *syncCache) keepEntries(paths ...string) {
+	c.keep = append(c.keep, paths...)
+}
+
+func saveCache(allocationObj *sdk.Allocation, cache *syncCache, exclPath []string) error {
+	if len(cache.path) > 0 {
+		err := allocationObj.SaveRemoteSnapshot(cache.path, exclPath)
+		if err == nil && len(cache.keep) > 0 {
+			err = cache.copyKeptEntries()
+		}
 		if err != nil {
-			PrintError("Failed to save local cache.", err)
-			os.Exit(1)
+			return commandError("Failed to save local cache.", err)
 		}
 		fmt.Println("Local cache saved.")
 	}
+	return nil
+}
+
+// copyKeptEntries adds the kept entries of the previous snapshot to the snapshot just saved
+func (c *syncCache) copyKeptEntries() error {
+	buf, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+	snapshot := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(buf, &snapshot); err != nil {
+		return err
+	}
+	for entry, v := range c.prev {
+		for _, p := range c.keep {
+			if entry == p || strings.HasPrefix(entry, strings.TrimRight(p, "/")+"/") {
+				snapshot[entry] = v
+			}
+		}
+	}
+	if buf, err = json.Marshal(snapshot); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, buf, 0644)
 }
 
 func filterOperations(lDiff []sdk.FileDiff) (filterDiff []sdk.FileDiff, exclPath []string) {
@@ -44,31 +106,66 @@ func filterOperations(lDiff []sdk.FileDiff) (filterDiff []sdk.FileDiff, exclPath
 	return
 }
 
-func commitDiff(lDiff []sdk.FileDiff, allocationObj *sdk.Allocation, fileMetas map[string]*sdk.ConsolidatedFileMeta) {
+// commitDiff commits the meta of the synced files, except of the failed paths. A failed commit does not stop the others,
+// the first error is returned.
+func commitDiff(lDiff []sdk.FileDiff, allocationObj *sdk.Allocation, fileMetas map[string]*sdk.ConsolidatedFileMeta, failed map[string]bool) error {
 	wg := &sync.WaitGroup{}
 	statusBar := &StatusBar{wg: wg}
+	var firstErr error
 	for _, f := range lDiff {
+		if failed[f.Path] {
+			continue
+		}
+		var err error
 		switch f.Op {
 		case sdk.Upload:
 			wg.Add(1)
-			commitMetaTxn(f.Path, "Upload", "", "", allocationObj, nil, statusBar)
+			err = commitMetaTxn(f.Path, "Upload", "", "", allocationObj, nil, statusBar)
 		case sdk.Update:
 			wg.Add(1)
-			commitMetaTxn(f.Path, "Update", "", "", allocationObj, nil, statusBar)
+			err = commitMetaTxn(f.Path, "Update", "", "", allocationObj, nil, statusBar)
 		case sdk.Download:
 			wg.Add(1)
-			commitMetaTxn(f.Path, "Download", "", "", allocationObj, nil, statusBar)
+			err = commitMetaTxn(f.Path, "Download", "", "", allocationObj, nil, statusBar)
 		case sdk.Delete:
 			fileMeta, ok := fileMetas[f.Path]
 			if !ok {
-				PrintError("Unable to commit metaData for :", f.Path)
+				err = commandError("Unable to commit metaData for :", f.Path)
 				break
 			}
 			wg.Add(1)
-			commitMetaTxn(f.Path, "Delete", "", "", allocationObj, fileMeta, statusBar)
+			err = commitMetaTxn(f.Path, "Delete", "", "", allocationObj, fileMeta, statusBar)
+		}
+		if err != nil {
+			PrintError(err.Error())
+			if firstErr == nil {
+				firstErr = err
+			}
 		}
 	}
 	statusBar.wg.Wait()
+	return firstErr
+}
+
+// deleteTask deletes the remote file rPath. Its meta is fetched first, as it is committed after the delete,
+// and stored in fileMetas once the file is deleted.
+func deleteTask(allocationObj *sdk.Allocation, rPath string, fileMetas map[string]*sdk.ConsolidatedFileMeta, mu *sync.Mutex) transferTask {
+	return transferTask{
+		RemotePath: rPath,
+		Run: func(status *transferStatus) error {
+			fileMeta, err := allocationObj.GetFileMeta(rPath)
+			if err != nil {
+				return fmt.Errorf("error fetching metaData: %v", err)
+			}
+			if err := allocationObj.DeleteFile(rPath); err != nil {
+				return fmt.Errorf("error deleting remote file: %v", err)
+			}
+			mu.Lock()
+			fileMetas[rPath] = fileMeta
+			mu.Unlock()
+			return nil
+		},
+	}
 }
 
 // syncCmd represents sync command
@@ -77,25 +174,22 @@ var syncCmd = &cobra.Command{
 	Short: "Sync files to/from blobbers",
 	Long:  `Sync all files to/from blobbers from/to a localpath`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags() // fflags is a *flag.FlagSet
 		if fflags.Changed("localpath") == false {
-			PrintError("Error: localpath flag is missing")
-			os.Exit(1)
+			return usageError("Error: localpath flag is missing")
 		}
 
 		localpath := cmd.Flag("localpath").Value.String()
 
 		if len(localpath) == 0 {
-			PrintError("Error: localpath flag is missing")
-			os.Exit(1)
+			return usageError("Error: localpath flag is missing")
 		}
 
 		encryptpath := cmd.Flag("encryptpath").Value.String()
 
 		if fflags.Changed("allocation") == false { // check if the flag "path" is set
-			PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-			os.Exit(1)                                      // and os.Exit(1)
+			return usageError("Error: allocation flag is missing")
 		}
 		allocationID := cmd.Flag("allocation").Value.String()
 
@@ -108,111 +202,160 @@ var syncCmd = &cobra.Command{
 			exclPath, _ = cmd.Flags().GetStringArray("excludepath")
 		}
 
+		pathFilter, err := newPathFilter(cmd, localpath)
+		if err != nil {
+			return err
+		}
+		cache, err := loadSyncCache(localcache)
+		if err != nil {
+			return commandError("Error reading local cache.", err)
+		}
+
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation", err)
 		}
 
+		// the meta of the deleted remote files, by their path, to commit them
 		fileMetas := make(map[string]*sdk.ConsolidatedFileMeta)
-		wg := &sync.WaitGroup{}
-		statusBar := &StatusBar{wg: wg}
-		// Create filter
-		filter := []string{".DS_Store", ".git"}
+		metasMu := &sync.Mutex{}
+
+		deletePolicy, _ := cmd.Flags().GetString("delete")
+		switch deletePolicy {
+		case deleteNever, deletePrompt, deleteAlways:
+		default:
+			return usageError("Error: invalid --delete " + deletePolicy + ", valid policies are never, prompt and always")
+		}
+		maxDelete, _ := cmd.Flags().GetInt("max-delete")
+		trashDir, _ := cmd.Flags().GetString("trash")
+		if len(trashDir) == 0 {
+			trashDir = syncTrashDir()
+		}
+		trashDir = newTrashDir(trashDir, time.Now())
 
 		uploadOnly, _ := cmd.Flags().GetBool("uploadonly")
 		commit, _ := cmd.Flags().GetBool("commit")
 		chunkSize, _ := cmd.Flags().GetInt("chunksize")
+		workers, _ := cmd.Flags().GetInt("workers")
 
-		lDiff, err := allocationObj.GetAllocationDiff(localcache, localpath, filter, exclPath)
+		lDiff, err := allocationObj.GetAllocationDiff(localcache, localpath, defaultIgnores, exclPath)
 		if err != nil {
-			PrintError("Error getting diff.", err)
-			os.Exit(1)
+			return commandError("Error getting diff.", err)
 		}
+		lDiff, skippedPaths := pathFilter.filterDiff(lDiff)
+		exclPath = append(exclPath, skippedPaths...)
 
 		if uploadOnly {
 			var otherPaths []string
 			lDiff, otherPaths = filterOperations(lDiff)
 			exclPath = append(exclPath, otherPaths...)
 		}
+		if deletePolicy == deleteNever {
+			var localDeletes []string
+			lDiff, localDeletes = filterDeletions(lDiff)
+			cache.keepEntries(localDeletes...)
+		}
+
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			printSyncPlan(cmd, newSyncPlan(allocationObj, strings.TrimRight(localpath, "/"), lDiff))
+			return nil
+		}
 
 		if len(lDiff) > 0 {
 			printTable(lDiff)
 		} else {
 			fmt.Println("Already up to date")
-			saveCache(allocationObj, localcache, exclPath)
-			return
+			return saveCache(allocationObj, cache, exclPath)
+		}
+		lDiff, declinedLocalDeletes, err := confirmDeletions(deletePolicy, maxDelete, lDiff)
+		if err != nil {
+			return err
 		}
+		cache.keepEntries(declinedLocalDeletes...)
+
+		var tasks []transferTask
 		for _, f := range lDiff {
+			if cmd.Context().Err() != nil {
+				break
+			}
 			localpath = strings.TrimRight(localpath, "/")
 			lPath := localpath + f.Path
+			rPath := f.Path
 			switch f.Op {
 			case sdk.Download:
-				wg.Add(1)
-				err = allocationObj.DownloadFile(lPath, f.Path, statusBar)
-			case sdk.Upload:
-				//wg.Add(1)
+				tasks = append(tasks, transferTask{
+					LocalPath:  lPath,
+					RemotePath: rPath,
+					Run: func(status *transferStatus) error {
+						status.wg.Add(1)
+						if err := allocationObj.DownloadFile(lPath, rPath, status); err != nil {
+							return err
+						}
+						return status.wait()
+					},
+				})
+			case sdk.Upload, sdk.Update:
 				var attrs fileref.Attributes
-
-				encrypt := len(encryptpath) != 0 && strings.Contains(lPath, encryptpath)
-
-				err = startChunkedUpload(cmd, allocationObj, lPath, "", f.Path, encrypt, chunkSize, attrs, statusBar, false)
-
-				// if len(encryptpath) != 0 && strings.Contains(lPath, encryptpath) {
-				// 	err = allocationObj.EncryptAndUploadFile(lPath, f.Path, attrs, statusBar)
-				// } else {
-				// 	err = allocationObj.UploadFile(lPath, f.Path, attrs, statusBar)
-				// }
-			case sdk.Update:
-				//wg.Add(1)
-
+				isUpdate := f.Op == sdk.Update
+				if isUpdate {
+					attrs = f.Attributes
+				}
 				encrypt := len(encryptpath) != 0 && strings.Contains(lPath, encryptpath)
 
-				err = startChunkedUpload(cmd, allocationObj, lPath, "", f.Path, encrypt, chunkSize, f.Attributes, statusBar, true)
-
-				// if len(encryptpath) != 0 && strings.Contains(lPath, encryptpath) {
-				// 	err = allocationObj.EncryptAndUpdateFile(lPath, f.Path,
-				// 		getRemoteFileAttributes(allocationObj, f.Path),
-				// 		statusBar)
-				// } else {
-				// 	err = allocationObj.UpdateFile(lPath, f.Path,
-				// 		getRemoteFileAttributes(allocationObj, f.Path),
-				// 		statusBar)
-				// }
-			case sdk.Delete:
-				fileMeta, err := allocationObj.GetFileMeta(f.Path)
-				if err != nil {
-					PrintError("Error fetching metaData :", err.Error())
-				}
-				fileMetas[f.Path] = fileMeta
-				// TODO: User confirm??
-				fmt.Printf("Deleting remote %s...\n", f.Path)
-				err = allocationObj.DeleteFile(f.Path)
-				if err != nil {
-					PrintError("Error deleting remote file,", err.Error())
+				var size int64
+				if fi, err := os.Stat(lPath); err == nil {
+					size = fi.Size()
 				}
-				continue
+				tasks = append(tasks, transferTask{
+					LocalPath:  lPath,
+					RemotePath: rPath,
+					Size:       size,
+					Run: func(status *transferStatus) error {
+						return uploadFile(cmd, allocationObj, lPath, rPath, encrypt, chunkSize, attrs, false, isUpdate, status)
+					},
+				})
+			case sdk.Delete:
+				tasks = append(tasks, deleteTask(allocationObj, rPath, fileMetas, metasMu))
 			case sdk.LocalDelete:
-				// TODO: User confirm??
-				fmt.Printf("Deleting local %s...\n", lPath)
-				err = os.RemoveAll(lPath)
+				trashPath, err := moveToTrash(trashDir, lPath, rPath)
 				if err != nil {
-					PrintError("Error deleting local file.", err.Error())
+					PrintError("Error moving local file to the trash.", err.Error())
+					break
 				}
-				continue
+				fmt.Printf("Moved local %s to %s\n", lPath, trashPath)
 			}
-			if err == nil {
-				//wg.Wait()
-			} else {
-				PrintError(err.Error())
+		}
+
+		var failed int
+		failedPaths := make(map[string]bool)
+		if len(tasks) > 0 {
+			results := newTransferScheduler(cmd.Context(), workers).Run(tasks)
+			failed = printTransferSummary(results)
+			for _, r := range results {
+				if r.Err != nil {
+					failedPaths[r.RemotePath] = true
+				}
 			}
 		}
+		var commitErr error
 		if commit {
-			commitDiff(lDiff, allocationObj, fileMetas)
+			commitErr = commitDiff(lDiff, allocationObj, fileMetas, failedPaths)
+		}
+		if err := interruptedError(cmd.Context()); err != nil {
+			// save what was synced so far, so the next sync starts from there
+			if cacheErr := saveCache(allocationObj, cache, exclPath); cacheErr != nil {
+				PrintError(cacheErr.Error())
+			}
+			return err
 		}
 		fmt.Println("\nSync Complete")
-		saveCache(allocationObj, localcache, exclPath)
-		return
+		if err := saveCache(allocationObj, cache, exclPath); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return transferFailedError(cmd.Context(), failed)
+		}
+		return commitErr
 	},
 }
 
@@ -222,24 +365,21 @@ var getDiffCmd = &cobra.Command{
 	Short: "Get difference of local and allocation root",
 	Long:  `Get difference of local and allocation root`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		fflags := cmd.Flags() // fflags is a *flag.FlagSet
 		if fflags.Changed("localpath") == false {
-			PrintError("Error: localpath flag is missing")
-			os.Exit(1)
+			return usageError("Error: localpath flag is missing")
 		}
 
 		localpath := cmd.Flag("localpath").Value.String()
 
 		if len(localpath) == 0 {
-			PrintError("Error: localpath flag is missing")
-			os.Exit(1)
+			return usageError("Error: localpath flag is missing")
 		}
 
 		if fflags.Changed("allocation") == false { // check if the flag "path" is set
-			PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-			os.Exit(1)                                      // and os.Exit(1)
+			return usageError("Error: allocation flag is missing")
 		}
 		allocationID := cmd.Flag("allocation").Value.String()
 
@@ -252,21 +392,24 @@ var getDiffCmd = &cobra.Command{
 			exclPath, _ = cmd.Flags().GetStringArray("excludepath")
 		}
 
+		pathFilter, err := newPathFilter(cmd, localpath)
+		if err != nil {
+			return err
+		}
+
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation", err)
 		}
 
-		// Create filter
-		filter := []string{".DS_Store", ".git"}
-		lDiff, err := allocationObj.GetAllocationDiff(localcache, localpath, filter, exclPath)
+		lDiff, err := allocationObj.GetAllocationDiff(localcache, localpath, defaultIgnores, exclPath)
 		if err != nil {
-			PrintError("Error getting diff.", err)
-			os.Exit(1)
+			return commandError("Error getting diff.", err)
 		}
+		lDiff, _ = pathFilter.filterDiff(lDiff)
 
 		util.PrintJSON(lDiff)
+		return nil
 	},
 }
 
@@ -288,6 +431,11 @@ After sync complete, remote snapshot will be updated to the same file for next u
 	syncCmd.Flags().Bool("commit", false, "pass this option to commit the metadata transaction - only works with uploadonly")
 
 	syncCmd.Flags().Int("chunksize", sdk.CHUNK_SIZE, "chunk size")
+	syncCmd.Flags().Int("workers", defaultTransferWorkers, "number of files uploaded/downloaded in parallel")
+	syncCmd.Flags().String("delete", deletePrompt, "deletions of remote and local files: never, prompt or always")
+	syncCmd.Flags().Int("max-delete", 0, "abort the sync if more files are to be deleted (default 0, no limit)")
+	syncCmd.Flags().String("trash", "", "directory local files deleted by sync are moved to (default is $HOME/.zcn/trash)")
+	syncCmd.Flags().Bool("dry-run", false, "pass this option to print the plan of the sync and its estimated cost, without syncing")
 
 	getDiffCmd.PersistentFlags().String("allocation", "", "Allocation ID")
 	getDiffCmd.PersistentFlags().String("localpath", "", "Local dir path to sync")
@@ -297,4 +445,6 @@ After sync complete, remote snapshot will be updated to the same file for next u
 	getDiffCmd.PersistentFlags().StringArray("excludepath", []string{}, "Remote folder paths exclude to sync")
 	getDiffCmd.MarkFlagRequired("allocation")
 	getDiffCmd.MarkFlagRequired("localpath")
+	addFilterFlags(syncCmd)
+	addFilterFlags(getDiffCmd)
 }
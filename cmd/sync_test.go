@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/0chain/gosdk/core/zcncrypto"
+	"github.com/0chain/gosdk/zboxcore/blockchain"
+	"github.com/0chain/gosdk/zboxcore/fileref"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/spf13/cobra"
+)
+
+// fakeBlobber serves the network of its block worker and lists the files of an allocation and their meta,
+// so sync diffs and file meta run against the sdk
+type fakeBlobber struct {
+	mu         sync.Mutex
+	files      map[string]string // content by remote path
+	chunkSizes map[string]int64  // chunk size files were uploaded with by remote path, unset for files which don't report it
+}
+
+func (b *fakeBlobber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	url := "http://" + r.Host
+	switch {
+	case r.URL.Path == "/network":
+		json.NewEncoder(w).Encode(map[string][]string{"miners": {url}, "sharders": {url}})
+	case strings.HasPrefix(r.URL.Path, "/v1/file/list/"):
+		dir := r.URL.Query().Get("path")
+		entry := func(p, t string) map[string]interface{} {
+			return map[string]interface{}{"type": t, "name": path.Base(p), "path": p,
+				"lookup_hash": fileref.GetReferenceLookup(testAllocationID, p)}
+		}
+		list := []map[string]interface{}{}
+		dirs := make(map[string]bool)
+		for p, content := range b.files {
+			rel := strings.TrimPrefix(p, strings.TrimRight(dir, "/")+"/")
+			if rel == p {
+				continue
+			}
+			if i := strings.Index(rel, "/"); i >= 0 {
+				sub := path.Join(dir, rel[:i])
+				if !dirs[sub] {
+					dirs[sub] = true
+					list = append(list, entry(sub, fileref.DIRECTORY))
+				}
+				continue
+			}
+			f := entry(p, fileref.FILE)
+			f["actual_file_hash"] = sha1Hex(content)
+			f["actual_file_size"] = len(content)
+			f["size"] = len(content)
+			list = append(list, f)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"meta_data": entry(dir, fileref.DIRECTORY), "list": list})
+	case strings.HasPrefix(r.URL.Path, "/v1/file/meta/"):
+		for p, content := range b.files {
+			if fileref.GetReferenceLookup(testAllocationID, p) == r.FormValue("path_hash") {
+				json.NewEncoder(w).Encode(map[string]interface{}{"type": fileref.FILE, "name": path.Base(p), "path": p,
+					"actual_file_hash": sha1Hex(content), "actual_file_size": len(content), "chunk_size": b.chunkSizes[p]})
+				return
+			}
+		}
+		http.NotFound(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (b *fakeBlobber) remove(p string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.files, p)
+}
+
+const testAllocationID = "testallocation"
+
+func sha1Hex(content string) string {
+	h := sha1.Sum([]byte(content))
+	return hex.EncodeToString(h[:])
+}
+
+// newTestAllocation returns an allocation of one fake blobber holding files, and a local directory holding the same files
+func newTestAllocation(t *testing.T, files map[string]string) (*sdk.Allocation, *fakeBlobber, string) {
+	blobber := &fakeBlobber{files: make(map[string]string), chunkSizes: make(map[string]int64)}
+	srv := httptest.NewServer(blobber)
+	t.Cleanup(srv.Close)
+	sdk.GetLogger().SetLevel(0)
+	// requests of file meta are signed with the keys of the wallet
+	wallet, err := zcncrypto.NewSignatureScheme("bls0chain").GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	walletJSON, _ := json.Marshal(wallet)
+	if err := sdk.InitStorageSDK(string(walletJSON), srv.URL, "chain", "bls0chain", nil); err != nil {
+		t.Fatal(err)
+	}
+	// consensus needs more than the data shards, so the blobber holds the parity shard too
+	allocationObj := &sdk.Allocation{ID: testAllocationID, Tx: testAllocationID, DataShards: 1, ParityShards: 1,
+		Blobbers: []*blockchain.StorageNode{{ID: "data", Baseurl: srv.URL}, {ID: "parity", Baseurl: srv.URL}}}
+	allocationObj.InitAllocation()
+
+	local := t.TempDir()
+	for p, content := range files {
+		blobber.files[p] = content
+		lPath := filepath.Join(local, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(lPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(lPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return allocationObj, blobber, local
+}
+
+// diffOps returns the operations of a diff by path
+func diffOps(lDiff []sdk.FileDiff) map[string]string {
+	ops := make(map[string]string)
+	for _, f := range lDiff {
+		ops[f.Path] = f.Op
+	}
+	return ops
+}
+
+func TestSkippedDeletionsArePlannedAgain(t *testing.T) {
+	allocationObj, blobber, local := newTestAllocation(t, map[string]string{"/a.txt": "a", "/b.txt": "b", "/c.txt": "c"})
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	// sync plans a diff and saves the snapshot, skipping the deletions if asked; the first sync only saves it
+	sync := func(skipDeletions bool) map[string]string {
+		cache, err := loadSyncCache(cachePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lDiff, err := allocationObj.GetAllocationDiff(cachePath, local, defaultIgnores, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ops := diffOps(lDiff)
+		if skipDeletions {
+			var localDeletes []string
+			lDiff, localDeletes = filterDeletions(lDiff)
+			cache.keepEntries(localDeletes...)
+		}
+		if len(lDiff) > 0 {
+			t.Fatalf("unexpected operations %v", diffOps(lDiff))
+		}
+		if err := saveCache(allocationObj, cache, nil); err != nil {
+			t.Fatal(err)
+		}
+		return ops
+	}
+	if ops := sync(false); len(ops) > 0 {
+		t.Fatalf("first sync planned %v", ops)
+	}
+
+	os.Remove(filepath.Join(local, "a.txt"))
+	blobber.remove("/b.txt")
+	want := map[string]string{"/a.txt": sdk.Delete, "/b.txt": sdk.LocalDelete}
+	for i := 0; i < 2; i++ {
+		ops := sync(true)
+		if len(ops) != len(want) {
+			t.Fatalf("sync %d planned %v, want %v", i+2, ops, want)
+		}
+		for p, op := range want {
+			if ops[p] != op {
+				t.Errorf("sync %d planned %s for %s, want %s", i+2, ops[p], p, op)
+			}
+		}
+	}
+}
+
+func TestFilteredPathsAreNotCached(t *testing.T) {
+	allocationObj, blobber, local := newTestAllocation(t, map[string]string{"/a.txt": "a"})
+	blobber.files["/build/out.bin"] = "out"
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	sync := func(excludes ...string) map[string]string {
+		cmd := &cobra.Command{}
+		addFilterFlags(cmd)
+		for _, e := range excludes {
+			cmd.Flags().Set("exclude", e)
+		}
+		pathFilter, err := newPathFilter(cmd, local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache, err := loadSyncCache(cachePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lDiff, err := allocationObj.GetAllocationDiff(cachePath, local, defaultIgnores, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lDiff, exclPath := pathFilter.filterDiff(lDiff)
+		if err := saveCache(allocationObj, cache, exclPath); err != nil {
+			t.Fatal(err)
+		}
+		return diffOps(lDiff)
+	}
+	if ops := sync("build"); len(ops) > 0 {
+		t.Fatalf("sync with --exclude build planned %v", ops)
+	}
+	// once the exclude is removed, the remote file is downloaded, not deleted as if it was deleted locally
+	if ops := sync(); len(ops) != 1 || ops["/build/out.bin"] != sdk.Download {
+		t.Fatalf("sync without --exclude planned %v, want a download of /build/out.bin", ops)
+	}
+}
+
+func TestFailedDeletionsAreCountedAndNotCommitted(t *testing.T) {
+	allocationObj, _, _ := newTestAllocation(t, map[string]string{"/a.txt": "a"})
+
+	// the meta of /missing.txt can't be fetched, /a.txt can't be deleted as the blobber serves no deletes
+	lDiff := []sdk.FileDiff{{Op: sdk.Delete, Path: "/a.txt"}, {Op: sdk.Delete, Path: "/missing.txt"}}
+	fileMetas := make(map[string]*sdk.ConsolidatedFileMeta)
+	var tasks []transferTask
+	for _, f := range lDiff {
+		tasks = append(tasks, deleteTask(allocationObj, f.Path, fileMetas, &sync.Mutex{}))
+	}
+	results := newTransferScheduler(context.Background(), 2).Run(tasks)
+
+	var failed int
+	captureStdout(t, func() { failed = printTransferSummary(results) })
+	if failed != len(lDiff) {
+		t.Fatalf("%d deletions failed, want %d: %+v", failed, len(lDiff), results)
+	}
+	if len(fileMetas) > 0 {
+		t.Errorf("the meta of failed deletions is kept to commit: %v", fileMetas)
+	}
+	failedPaths := make(map[string]bool)
+	for _, r := range results {
+		failedPaths[r.RemotePath] = true
+	}
+	if err := commitDiff(lDiff, allocationObj, fileMetas, failedPaths); err != nil {
+		t.Errorf("failed deletions are committed: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/0chain/zboxcli/util"
+)
+
+// policies of the --delete flag of sync
+const (
+	deleteNever  = "never"
+	deletePrompt = "prompt"
+	deleteAlways = "always"
+)
+
+// syncTrashDir is the directory local files deleted by sync are moved to, unless --trash is set
+func syncTrashDir() string {
+	return filepath.Join(util.GetHomeDir(), ".zcn", "trash")
+}
+
+// isDeletion tells if a sync operation deletes a remote or local file
+func isDeletion(f sdk.FileDiff) bool {
+	return f.Op == sdk.Delete || f.Op == sdk.LocalDelete
+}
+
+// filterDeletions removes the deletions of lDiff, and returns the paths of the local deletions removed,
+// whose entries the local cache keeps so the next sync plans them again.
+// Remote files which are not deleted stay in the snapshot of the remote anyway.
+func filterDeletions(lDiff []sdk.FileDiff) (filterDiff []sdk.FileDiff, localDeletes []string) {
+	for _, f := range lDiff {
+		if !isDeletion(f) {
+			filterDiff = append(filterDiff, f)
+		} else if f.Op == sdk.LocalDelete {
+			localDeletes = append(localDeletes, f.Path)
+		}
+	}
+	return
+}
+
+// confirmDeletions applies --max-delete and the prompt of --delete prompt to the deletions of lDiff.
+// Declined deletions are removed from lDiff, and the paths of the local ones returned, as filterDeletions does.
+func confirmDeletions(policy string, maxDelete int, lDiff []sdk.FileDiff) ([]sdk.FileDiff, []string, error) {
+	var remote, local int
+	for _, f := range lDiff {
+		switch f.Op {
+		case sdk.Delete:
+			remote++
+		case sdk.LocalDelete:
+			local++
+		}
+	}
+	if remote+local == 0 {
+		return lDiff, nil, nil
+	}
+	if maxDelete > 0 && remote+local > maxDelete {
+		return nil, nil, commandError(fmt.Sprintf("Error: %d deletions planned, more than --max-delete %d. Nothing was synced", remote+local, maxDelete))
+	}
+	if policy != deletePrompt {
+		return lDiff, nil, nil
+	}
+
+	ok, err := promptConfirm(fmt.Sprintf("Delete %d remote and %d local file(s)? Type yes to delete them: ", remote, local), "yes")
+	if err != nil {
+		return nil, nil, commandError("Error:", err, "- use --delete always or --delete never")
+	}
+	if ok {
+		return lDiff, nil, nil
+	}
+	fmt.Printf("Skipping %d deletion(s)\n", remote+local)
+	lDiff, localDeletes := filterDeletions(lDiff)
+	return lDiff, localDeletes, nil
+}
+
+// moveToTrash moves the local file or directory lPath to trashDir, under its path rPath in the allocation.
+// It is copied and removed if it can't be renamed, as when trashDir is on another file system.
+func moveToTrash(trashDir, lPath, rPath string) (string, error) {
+	dest := filepath.Join(trashDir, filepath.FromSlash(rPath))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(lPath, dest); err == nil {
+		return dest, nil
+	}
+	if err := copyTree(lPath, dest); err != nil {
+		return "", err
+	}
+	return dest, os.RemoveAll(lPath)
+}
+
+// copyTree copies the file or directory src to dest
+func copyTree(src, dest string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// newTrashDir returns the directory of the local files deleted by a sync started at t
+func newTrashDir(root string, t time.Time) string {
+	return filepath.Join(root, t.Format("20060102-150405"))
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/0chain/gosdk/core/common"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/0chain/zboxcli/util"
+	"github.com/spf13/cobra"
+)
+
+// syncOperations are the operations of a sync plan, in the order they are printed
+var syncOperations = []string{sdk.Upload, sdk.Update, sdk.Download, sdk.Delete, sdk.LocalDelete, sdk.Conflict}
+
+// syncPlanFile is a file of a sync plan, with its local and remote size and modification time.
+// Times are unix seconds, zero when the file does not exist on that side.
+type syncPlanFile struct {
+	Path           string         `json:"path"`
+	Type           string         `json:"type"`
+	LocalSize      int64          `json:"local_size"`
+	LocalModified  int64          `json:"local_modified"`
+	RemoteSize     int64          `json:"remote_size"`
+	RemoteModified int64          `json:"remote_modified"`
+	TransferBytes  int64          `json:"transfer_bytes"`
+	Cost           common.Balance `json:"cost"`
+}
+
+// syncPlanOperation is the files of an operation of a sync plan
+type syncPlanOperation struct {
+	Operation     string          `json:"operation"`
+	Files         []*syncPlanFile `json:"files"`
+	TransferBytes int64           `json:"transfer_bytes"`
+	Cost          common.Balance  `json:"cost"`
+}
+
+// syncPlan is what sync --dry-run prints. The upload cost is per time unit of the allocation,
+// as get-upload-cost prints it, the download cost is for reading the files once.
+type syncPlan struct {
+	Operations    []*syncPlanOperation `json:"operations"`
+	TransferBytes int64                `json:"transfer_bytes"`
+	UploadCost    common.Balance       `json:"upload_cost"`
+	DownloadCost  common.Balance       `json:"download_cost"`
+	TimeUnit      time.Duration        `json:"time_unit"`
+}
+
+// remoteFiles lists the remote directories of the files of lDiff, and returns the files by path
+func remoteFiles(allocationObj *sdk.Allocation, lDiff []sdk.FileDiff) map[string]*sdk.ListResult {
+	files := make(map[string]*sdk.ListResult)
+	listed := make(map[string]bool)
+	for _, f := range lDiff {
+		dir := path.Dir(f.Path)
+		if listed[dir] {
+			continue
+		}
+		listed[dir] = true
+		list, err := allocationObj.ListDir(dir)
+		if err != nil {
+			PrintError("Error listing remote", dir+":", err.Error())
+			continue
+		}
+		for _, child := range list.Children {
+			files[child.Path] = child
+		}
+	}
+	return files
+}
+
+// parseRemoteTime returns the unix seconds of a created_at or updated_at of a blobber, zero if it can't be parsed
+func parseRemoteTime(v string) int64 {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.Unix()
+		}
+	}
+	return 0
+}
+
+// newSyncPlan groups the files of lDiff by operation, with their sizes and modification times,
+// the bytes each transfers and what it costs with the terms of the blobbers of the allocation
+func newSyncPlan(allocationObj *sdk.Allocation, localpath string, lDiff []sdk.FileDiff) *syncPlan {
+	remote := remoteFiles(allocationObj, lDiff)
+	plan := &syncPlan{TimeUnit: allocationObj.TimeUnit}
+	byOp := make(map[string]*syncPlanOperation)
+	for _, f := range lDiff {
+		file := &syncPlanFile{Path: f.Path, Type: f.Type}
+		if fi, err := os.Stat(localpath + f.Path); err == nil {
+			file.LocalSize, file.LocalModified = fi.Size(), fi.ModTime().Unix()
+		}
+		if r, ok := remote[f.Path]; ok {
+			file.RemoteSize = r.ActualSize
+			if file.RemoteSize == 0 {
+				file.RemoteSize = r.Size
+			}
+			file.RemoteModified = parseRemoteTime(r.UpdatedAt)
+		}
+
+		switch f.Op {
+		case sdk.Upload, sdk.Update:
+			file.TransferBytes = file.LocalSize
+			file.Cost = uploadCostForSize(allocationObj, file.LocalSize)
+			plan.UploadCost += file.Cost
+		case sdk.Download:
+			file.TransferBytes = file.RemoteSize
+			file.Cost, _ = downloadCostForSize(allocationObj, file.RemoteSize)
+			plan.DownloadCost += file.Cost
+		}
+
+		op, ok := byOp[f.Op]
+		if !ok {
+			op = &syncPlanOperation{Operation: f.Op}
+			byOp[f.Op] = op
+		}
+		op.Files = append(op.Files, file)
+		op.TransferBytes += file.TransferBytes
+		op.Cost += file.Cost
+		plan.TransferBytes += file.TransferBytes
+	}
+	for _, name := range syncOperations {
+		if op, ok := byOp[name]; ok {
+			plan.Operations = append(plan.Operations, op)
+		}
+	}
+	return plan
+}
+
+// formatPlanTime returns unix seconds as a local time, empty for zero
+func formatPlanTime(t int64) string {
+	if t == 0 {
+		return ""
+	}
+	return time.Unix(t, 0).Format("2006-01-02 15:04:05")
+}
+
+// formatPlanSize returns a size, empty when the file does not exist on that side
+func formatPlanSize(size, modified int64) string {
+	if size == 0 && modified == 0 {
+		return ""
+	}
+	return common.Size(size).String()
+}
+
+// printSyncPlan prints the plan of a sync as tables grouped by operation, or as --output
+func printSyncPlan(cmd *cobra.Command, plan *syncPlan) {
+	header := []string{"operation", "path", "type", "local_size", "local_modified", "remote_size", "remote_modified", "transfer_bytes", "cost"}
+	var data [][]string
+	for _, op := range plan.Operations {
+		for _, f := range op.Files {
+			data = append(data, []string{op.Operation, f.Path, f.Type,
+				strconv.FormatInt(f.LocalSize, 10), strconv.FormatInt(f.LocalModified, 10),
+				strconv.FormatInt(f.RemoteSize, 10), strconv.FormatInt(f.RemoteModified, 10),
+				strconv.FormatInt(f.TransferBytes, 10), strconv.FormatInt(int64(f.Cost), 10)})
+		}
+	}
+	printOutput(cmd, plan, header, data, func() {
+		if len(plan.Operations) == 0 {
+			fmt.Println("Already up to date")
+			return
+		}
+		for _, op := range plan.Operations {
+			fmt.Printf("%s: %d file(s), %s\n", op.Operation, len(op.Files), common.Size(op.TransferBytes))
+			rows := make([][]string, len(op.Files))
+			for idx, f := range op.Files {
+				rows[idx] = []string{f.Path, formatPlanSize(f.LocalSize, f.LocalModified), formatPlanTime(f.LocalModified),
+					formatPlanSize(f.RemoteSize, f.RemoteModified), formatPlanTime(f.RemoteModified)}
+			}
+			util.WriteTable(os.Stdout, []string{"Path", "Local Size", "Local Modified", "Remote Size", "Remote Modified"}, []string{}, rows)
+			fmt.Println("")
+		}
+		fmt.Printf("Total to transfer: %s (%d bytes)\n", common.Size(plan.TransferBytes), plan.TransferBytes)
+		fmt.Printf("Estimated cost: %s tokens / %s to upload, %s tokens to download\n", plan.UploadCost, plan.TimeUnit, plan.DownloadCost)
+	})
+}
@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	coreutil "github.com/0chain/gosdk/core/util"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/0chain/zboxcli/util"
+	"gopkg.in/cheggaaa/pb.v1"
+)
+
+// defaultTransferWorkers is how many files multi-file transfers run in parallel by default
+const defaultTransferWorkers = 4
+
+// transferTask is a single file transfer run by transferScheduler
+type transferTask struct {
+	LocalPath  string
+	RemotePath string
+	// Size expected bytes to transfer, used by aggregate progress until the transfer is started
+	Size int64
+	// Run starts the transfer with status as its callback and returns once it is done
+	Run func(status *transferStatus) error
+}
+
+// errTransferCanceled is the result of tasks which were not started because the command was interrupted
+var errTransferCanceled = errors.New("canceled by interrupt")
+
+// transferScheduler runs transfer tasks on a fixed number of workers and renders their progress
+type transferScheduler struct {
+	ctx     context.Context
+	workers int
+
+	pool  *pb.Pool
+	total *pb.ProgressBar
+
+	mu         sync.Mutex
+	totalBytes int64
+
+	count    int64
+	finished int64
+}
+
+func newTransferScheduler(ctx context.Context, workers int) *transferScheduler {
+	if workers < 1 {
+		workers = 1
+	}
+	return &transferScheduler{ctx: ctx, workers: workers}
+}
+
+// Run runs all tasks and returns their results in the same order as tasks.
+// Once ctx is done no new task is started, tasks in flight are left to the sdk to stop.
+func (s *transferScheduler) Run(tasks []transferTask) []transferResult {
+	results := make([]transferResult, len(tasks))
+	if len(tasks) == 0 {
+		return results
+	}
+
+	workers := s.workers
+	if workers > len(tasks) {
+		workers = len(tasks)
+	}
+
+	s.totalBytes = 0
+	for _, t := range tasks {
+		s.totalBytes += t.Size
+	}
+	s.count = int64(len(tasks))
+	s.total = pb.New64(s.totalBytes).SetUnits(pb.U_BYTES)
+	s.total.Prefix(s.totalPrefix())
+
+	bars := make([]*pb.ProgressBar, workers)
+	for i := range bars {
+		bars[i] = pb.New(0).SetUnits(pb.U_BYTES)
+	}
+
+	// progress is only rendered when attached to a terminal
+	pool, err := pb.StartPool(append([]*pb.ProgressBar{s.total}, bars...)...)
+	if err == nil {
+		s.pool = pool
+	}
+
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(bar *pb.ProgressBar) {
+			defer wg.Done()
+			for idx := range jobs {
+				t := tasks[idx]
+				bar.Reset(int(t.Size))
+				bar.Prefix(shortTransferName(t.LocalPath, t.RemotePath) + " ")
+				status := &transferStatus{scheduler: s, bar: bar, expected: t.Size}
+				err := t.Run(status)
+				results[idx] = transferResult{LocalPath: t.LocalPath, RemotePath: t.RemotePath, Err: err}
+				s.total.Prefix(s.totalPrefixAfterFinish())
+			}
+		}(bars[i])
+	}
+	for idx, t := range tasks {
+		if s.ctx.Err() != nil {
+			results[idx] = transferResult{LocalPath: t.LocalPath, RemotePath: t.RemotePath, Err: errTransferCanceled}
+			continue
+		}
+		select {
+		case jobs <- idx:
+		case <-s.ctx.Done():
+			results[idx] = transferResult{LocalPath: t.LocalPath, RemotePath: t.RemotePath, Err: errTransferCanceled}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if s.pool != nil {
+		s.total.Finish()
+		for _, bar := range bars {
+			bar.Finish()
+		}
+		s.pool.Stop()
+	}
+
+	return results
+}
+
+// growTotal adjusts aggregate progress once the real size of a transfer is known
+func (s *transferScheduler) growTotal(delta int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totalBytes += delta
+	s.total.SetTotal64(s.totalBytes)
+}
+
+func (s *transferScheduler) totalPrefix() string {
+	return fmt.Sprintf("%d/%d files ", atomic.LoadInt64(&s.finished), s.count)
+}
+
+func (s *transferScheduler) totalPrefixAfterFinish() string {
+	atomic.AddInt64(&s.finished, 1)
+	return s.totalPrefix()
+}
+
+func shortTransferName(localPath, remotePath string) string {
+	name := filepath.Base(localPath)
+	if len(localPath) == 0 {
+		name = filepath.Base(remotePath)
+	}
+	if len(name) > 30 {
+		name = name[:27] + "..."
+	}
+	return name
+}
+
+// transferStatus is the sdk.StatusCallback of a task run by transferScheduler.
+// Unlike StatusBar it never prints, errors are returned by wait and reported in transferResult.
+type transferStatus struct {
+	scheduler *transferScheduler
+	bar       *pb.ProgressBar
+
+	expected  int64
+	completed int64
+
+	wg      sync.WaitGroup
+	success bool
+	err     error
+}
+
+// wait waits for the callback of the operation started after wg.Add(1)
+func (s *transferStatus) wait() error {
+	s.wg.Wait()
+	if s.success {
+		return nil
+	}
+	if s.err != nil {
+		return s.err
+	}
+	return errors.New("transfer failed")
+}
+
+func (s *transferStatus) Started(allocationId, filePath string, op int, totalBytes int) {
+	s.bar.Reset(totalBytes)
+	s.scheduler.growTotal(int64(totalBytes) - s.expected)
+	s.expected = int64(totalBytes)
+}
+
+func (s *transferStatus) InProgress(allocationId, filePath string, op int, completedBytes int, data []byte) {
+	s.bar.Set(completedBytes)
+	s.scheduler.total.Add64(int64(completedBytes) - s.completed)
+	s.completed = int64(completedBytes)
+}
+
+func (s *transferStatus) Completed(allocationId, filePath string, filename string, mimetype string, size int, op int) {
+	s.scheduler.total.Add64(s.expected - s.completed)
+	s.completed = s.expected
+	s.bar.Set64(s.expected)
+	s.success = true
+	s.wg.Done()
+}
+
+func (s *transferStatus) Error(allocationID string, filePath string, op int, err error) {
+	s.success = false
+	s.err = err
+	s.wg.Done()
+}
+
+func (s *transferStatus) CommitMetaCompleted(request, response string, err error) {
+	s.success = err == nil
+	s.err = err
+	s.wg.Done()
+}
+
+func (s *transferStatus) RepairCompleted(filesRepaired int) {
+	s.wg.Done()
+}
+
+// transferResult is the outcome of a single file in a multi-file transfer
+type transferResult struct {
+	LocalPath  string
+	RemotePath string
+	Skipped    bool
+	Err        error
+}
+
+// printTransferSummary prints per-file results of a multi-file transfer and returns the number of failed files
+func printTransferSummary(results []transferResult) (failed int) {
+	var skipped int
+	header := []string{"Local Path", "Remote Path", "Status"}
+	data := make([][]string, len(results))
+	for idx, r := range results {
+		status := "OK"
+		if r.Err != nil {
+			status = "FAILED: " + r.Err.Error()
+			failed++
+		} else if r.Skipped {
+			status = "SKIPPED (up to date)"
+			skipped++
+		}
+		data[idx] = []string{r.LocalPath, r.RemotePath, status}
+	}
+	fmt.Println("")
+	util.WriteTable(os.Stdout, header, []string{}, data)
+	fmt.Printf("\n%d file(s) transferred, %d skipped, %d failed\n", len(results)-failed-skipped, skipped, failed)
+	return
+}
+
+// computeFileHash computes the merkle root of a local file the same way the sdk computes ActualFileHash on upload
+func computeFileHash(localPath string, chunkSize int64, dataShards int, encrypted bool) (string, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := newMerkleHasher(chunkSize, dataShards, encrypted)
+	if _, err = io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hasher.Hash()
+}
+
+// merkleHasher computes ActualFileHash of content written to it, see computeFileHash
+type merkleHasher struct {
+	tree      *coreutil.CompactMerkleTree
+	blockSize int
+	block     []byte
+	idx       int
+}
+
+func newMerkleHasher(chunkSize int64, dataShards int, encrypted bool) *merkleHasher {
+	if encrypted {
+		chunkSize -= 16 + 2*1024
+	}
+	blockSize := int(chunkSize) * dataShards
+	return &merkleHasher{
+		tree:      coreutil.NewCompactMerkleTree(nil),
+		blockSize: blockSize,
+		block:     make([]byte, 0, blockSize),
+	}
+}
+
+func (h *merkleHasher) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		l := h.blockSize - len(h.block)
+		if l > len(p) {
+			l = len(p)
+		}
+		h.block = append(h.block, p[:l]...)
+		p = p[l:]
+		if len(h.block) == h.blockSize {
+			if err := h.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (h *merkleHasher) flush() error {
+	if err := h.tree.AddDataBlocks(h.block, h.idx); err != nil {
+		return err
+	}
+	h.idx++
+	h.block = h.block[:0]
+	return nil
+}
+
+// Hash returns the merkle root of everything written so far, it must be called once all content is written
+func (h *merkleHasher) Hash() (string, error) {
+	if len(h.block) > 0 {
+		if err := h.flush(); err != nil {
+			return "", err
+		}
+	}
+	return h.tree.GetMerkleRoot(), nil
+}
+
+// integrityError is returned by --verify when local content does not match the hash blobbers hold for it
+type integrityError struct {
+	RemotePath string
+	LocalHash  string
+	RemoteHash string
+}
+
+func (e *integrityError) Error() string {
+	return fmt.Sprintf("integrity check failed for %s: local hash %s does not match remote hash %s", e.RemotePath, e.LocalHash, e.RemoteHash)
+}
+
+// checkIntegrity compares localHash with remoteHash, the hash blobbers hold for remotePath
+func checkIntegrity(remotePath, localHash, remoteHash string) error {
+	if localHash != remoteHash {
+		return &integrityError{RemotePath: remotePath, LocalHash: localHash, RemoteHash: remoteHash}
+	}
+	return nil
+}
+
+// remoteChunkSize returns the chunk size remotePath was uploaded with, as blobbers report it in its file ref,
+// or fallback when they don't, as for files uploaded before the chunk size was stored
+func remoteChunkSize(allocationObj *sdk.Allocation, remotePath string, fallback int64) int64 {
+	_, _, ref, err := allocationObj.RepairRequired(remotePath)
+	if err != nil || ref == nil || ref.ChunkSize <= 0 {
+		return fallback
+	}
+	return ref.ChunkSize
+}
+
+// verifyDownload recomputes the hash of localPath, uploaded in chunks of chunkSize, and compares it with the hash of the remote file
+func verifyDownload(localPath, remotePath, remoteHash string, chunkSize int64, dataShards int, encrypted bool) error {
+	localHash, err := computeFileHash(localPath, chunkSize, dataShards, encrypted)
+	if err != nil {
+		return err
+	}
+	return checkIntegrity(remotePath, localHash, remoteHash)
+}
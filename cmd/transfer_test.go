@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/0chain/gosdk/zboxcore/blockchain"
+	"github.com/0chain/gosdk/zboxcore/fileref"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+)
+
+// uploadBlobber accepts the chunks of chunked uploads, and keeps the hash of the original file the sdk sends with the last one
+type uploadBlobber struct {
+	mu         sync.Mutex
+	actualHash string
+}
+
+func (b *uploadBlobber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/network":
+		url := "http://" + r.Host
+		json.NewEncoder(w).Encode(map[string][]string{"miners": {url}, "sharders": {url}})
+	case strings.HasPrefix(r.URL.Path, "/v1/file/upload/"):
+		var meta struct {
+			Filename   string `json:"filename"`
+			ChunkHash  string `json:"chunk_hash"`
+			ActualHash string `json:"actual_hash"`
+		}
+		if err := json.Unmarshal([]byte(r.FormValue("uploadMeta")), &meta); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(meta.ActualHash) > 0 {
+			b.mu.Lock()
+			b.actualHash = meta.ActualHash
+			b.mu.Unlock()
+		}
+		json.NewEncoder(w).Encode(map[string]string{"filename": meta.Filename, "content_hash": meta.ChunkHash})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// sdkFileHash uploads content with the sdk and returns the ActualFileHash it computes for it
+func sdkFileHash(t *testing.T, content []byte, chunkSize int64, dataShards int, encrypt bool) string {
+	blobber := &uploadBlobber{}
+	srv := httptest.NewServer(blobber)
+	defer srv.Close()
+	sdk.GetLogger().SetLevel(0)
+	// the mnemonic is the key encrypted uploads derive their encryption key from
+	wallet := `{"client_id":"client","client_key":"key","mnemonics":"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"}`
+	if err := sdk.InitStorageSDK(wallet, srv.URL, "chain", "bls0chain", nil); err != nil {
+		t.Fatal(err)
+	}
+	allocationObj := &sdk.Allocation{ID: testAllocationID, Tx: testAllocationID, DataShards: dataShards, ParityShards: 1}
+	for i := 0; i <= dataShards; i++ {
+		allocationObj.Blobbers = append(allocationObj.Blobbers, &blockchain.StorageNode{ID: string(rune('a' + i)), Baseurl: srv.URL})
+	}
+
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(localPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	fileMeta := sdk.FileMeta{Path: localPath, ActualSize: int64(len(content)), MimeType: "application/octet-stream",
+		RemoteName: "file", RemotePath: "/file", Attributes: fileref.Attributes{}}
+	upload, err := sdk.CreateChunkedUpload(dir, allocationObj, fileMeta, strings.NewReader(string(content)), false,
+		sdk.WithChunkSize(chunkSize), sdk.WithEncrypt(encrypt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the commit fails, the blobber only takes the chunks
+	upload.Start()
+
+	blobber.mu.Lock()
+	defer blobber.mu.Unlock()
+	if len(blobber.actualHash) == 0 {
+		t.Fatal("the sdk sent no hash of the file")
+	}
+	return blobber.actualHash
+}
+
+func TestComputeFileHashMatchesSDK(t *testing.T) {
+	const chunkSize = 64 * 1024
+	encryptedBlock := chunkSize - 16 - 2*1024
+	tests := []struct {
+		name       string
+		size       int
+		chunkSize  int64
+		dataShards int
+		encrypt    bool
+	}{
+		{"smaller than a chunk", 1000, chunkSize, 2, false},
+		{"multiple of the chunks of the data shards", 3 * 2 * chunkSize, chunkSize, 2, false},
+		{"not a multiple of the chunk size", 3*2*chunkSize + 777, chunkSize, 2, false},
+		{"one data shard", 2*chunkSize + 1, chunkSize, 1, false},
+		{"other chunk size", 5*32*1024 + 5, 32 * 1024, 2, false},
+		{"encrypted smaller than a chunk", 1000, chunkSize, 2, true},
+		{"encrypted multiple of the encrypted chunks", 3 * 2 * encryptedBlock, chunkSize, 2, true},
+		{"encrypted not a multiple of the chunk size", 3*2*chunkSize + 777, chunkSize, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := make([]byte, tt.size)
+			rand.New(rand.NewSource(int64(tt.size))).Read(content)
+			want := sdkFileHash(t, content, tt.chunkSize, tt.dataShards, tt.encrypt)
+
+			localPath := filepath.Join(t.TempDir(), "file")
+			if err := ioutil.WriteFile(localPath, content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := computeFileHash(localPath, tt.chunkSize, tt.dataShards, tt.encrypt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("computeFileHash is %s, the sdk hash is %s", got, want)
+			}
+
+			// the hasher of streamed uploads gets the content in writes of any size
+			hasher := newMerkleHasher(tt.chunkSize, tt.dataShards, tt.encrypt)
+			for p := content; len(p) > 0; {
+				n := 1 + rand.Intn(10000)
+				if n > len(p) {
+					n = len(p)
+				}
+				hasher.Write(p[:n])
+				p = p[n:]
+			}
+			if got, _ := hasher.Hash(); got != want {
+				t.Errorf("merkleHasher written in pieces is %s, the sdk hash is %s", got, want)
+			}
+		})
+	}
+}
+
+func TestRemoteChunkSize(t *testing.T) {
+	allocationObj, blobber, _ := newTestAllocation(t, map[string]string{"/big.bin": "big", "/old.bin": "old"})
+	blobber.chunkSizes["/big.bin"] = 1024 * 1024
+
+	tests := []struct {
+		remotePath string
+		want       int64
+	}{
+		{"/big.bin", 1024 * 1024},
+		{"/old.bin", sdk.CHUNK_SIZE},
+		{"/missing.bin", sdk.CHUNK_SIZE},
+	}
+	for _, tt := range tests {
+		if got := remoteChunkSize(allocationObj, tt.remotePath, sdk.CHUNK_SIZE); got != tt.want {
+			t.Errorf("chunk size of %s is %d, want %d", tt.remotePath, got, tt.want)
+		}
+	}
+}
@@ -12,39 +12,40 @@ var transferAllocationCmd = &cobra.Command{
 	Short: "Transfer an allocation between owners",
 	Long:  "Transfer an allocation between owners, only a curator can transfer an allocation",
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var err error
 		var flags = cmd.Flags()
 
 		if flags.Changed("allocation") == false {
-			log.Fatal("Error: curator flag is missing")
+			return usageError("Error: curator flag is missing")
 		}
 		allocationId, err := flags.GetString("allocation")
 		if err != nil {
-			log.Fatal("invalid 'allocation_id' flag: ", err)
+			return usageError("invalid 'allocation_id' flag: ", err)
 		}
 
 		if flags.Changed("new_owner") == false {
-			log.Fatal("Error: curator flag is missing")
+			return usageError("Error: curator flag is missing")
 		}
 		newOwnerId, err := flags.GetString("new_owner")
 		if err != nil {
-			log.Fatal("invalid 'new_owner_id' flag: ", err)
+			return usageError("invalid 'new_owner_id' flag: ", err)
 		}
 
 		if flags.Changed("new_owner_key") == false {
-			log.Fatal("Error: curator flag is missing")
+			return usageError("Error: curator flag is missing")
 		}
 		newOwnerPublicKey, err := flags.GetString("new_owner_key")
 		if err != nil {
-			log.Fatal("invalid 'new_owner_key' flag: ", err)
+			return usageError("invalid 'new_owner_key' flag: ", err)
 		}
 
 		_, err = sdk.CuratorTransferAllocation(allocationId, newOwnerId, newOwnerPublicKey)
 		if err != nil {
-			log.Fatal("Error adding curator:", err)
+			return commandError("Error adding curator:", err)
 		}
 		log.Print("transferred ownership of allocation " + allocationId + " to " + newOwnerId)
+		return nil
 	},
 }
 
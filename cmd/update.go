@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"log"
-	"os"
 	"sync"
 
 	"github.com/0chain/gosdk/zboxcore/fileref"
@@ -11,13 +9,13 @@ import (
 )
 
 func getRemoteFileAttributes(alloc *sdk.Allocation, remotePath string) (
-	attrs fileref.Attributes) {
+	attrs fileref.Attributes, err error) {
 
 	fileMeta, err := alloc.GetFileMeta(remotePath)
 	if err != nil {
-		log.Fatal("Unable to fetch existing file meta data for update")
+		return attrs, commandError("Unable to fetch existing file meta data for update", err)
 	}
-	return fileMeta.Attributes
+	return fileMeta.Attributes, nil
 }
 
 // updateCmd represents update file command
@@ -26,34 +24,32 @@ var updateCmd = &cobra.Command{
 	Short: "update file to blobbers",
 	Long:  `update file to blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags()
 		if fflags.Changed("allocation") == false {
-			PrintError("Error: allocation flag is missing")
-			os.Exit(1)
+			return usageError("Error: allocation flag is missing")
 		}
 		if fflags.Changed("remotepath") == false {
-			PrintError("Error: remotepath flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath flag is missing")
 		}
 		if !fflags.Changed("localpath") {
-			PrintError("Error: localpath flag is missing")
-			os.Exit(1)
+			return usageError("Error: localpath flag is missing")
 		}
 		allocationID := cmd.Flag("allocation").Value.String()
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation", err)
 		}
 		remotepath := cmd.Flag("remotepath").Value.String()
 
 		if remotepath == "/Encrypted" {
-			PrintError("Error: can not update Encrypted Folder")
-			os.Exit(1)
+			return commandError("Error: can not update Encrypted Folder")
 		}
 		// get original file attributes
-		var attrs = getRemoteFileAttributes(allocationObj, remotepath)
+		attrs, err := getRemoteFileAttributes(allocationObj, remotepath)
+		if err != nil {
+			return err
+		}
 
 		localpath := cmd.Flag("localpath").Value.String()
 		thumbnailpath := cmd.Flag("thumbnailpath").Value.String()
@@ -65,24 +61,25 @@ var updateCmd = &cobra.Command{
 		statusBar := &StatusBar{wg: wg}
 		wg.Add(1)
 
-		err = startChunkedUpload(cmd, allocationObj, localpath, thumbnailpath, remotepath, encrypt, chunkSize, attrs, statusBar, true)
+		err = startChunkedUpload(cmd.Context(), allocationObj, localpath, thumbnailpath, remotepath, encrypt, chunkSize, attrs, statusBar, true)
 
 		if err != nil {
-			PrintError("Update failed.", err)
-			os.Exit(1)
+			return commandError("Update failed.", err)
 		}
 
 		wg.Wait()
 		if !statusBar.success {
-			os.Exit(1)
+			return transferError(cmd.Context(), "Update failed.", statusBar.err)
 		}
 
 		if commit {
 			statusBar.wg.Add(1)
-			commitMetaTxn(remotepath, "Update", "", "", allocationObj, nil, statusBar)
+			if err := commitMetaTxn(remotepath, "Update", "", "", allocationObj, nil, statusBar); err != nil {
+				return err
+			}
 			statusBar.wg.Wait()
 		}
-		return
+		return nil
 	},
 }
 
@@ -15,51 +15,54 @@ var updateAllocationCmd = &cobra.Command{
 	Short: "Updates allocation's expiry and size",
 	Long:  `Updates allocation's expiry and size`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var flags = cmd.Flags()
 		if flags.Changed("allocation") == false {
-			log.Fatal("Error: allocation flag is missing")
+			return usageError("Error: allocation flag is missing")
 		}
 
 		allocID, err := flags.GetString("allocation")
 		if err != nil {
-			log.Fatal("invalid 'allocation_id' flag: ", err)
+			return usageError("invalid 'allocation_id' flag: ", err)
 		}
 
 		if flags.Changed("free_storage") {
-			lock, freeStorageMarker := processFreeStorageFlags(flags)
+			lock, freeStorageMarker, err := processFreeStorageFlags(flags)
+			if err != nil {
+				return err
+			}
 
 			txnHash, err := sdk.CreateFreeUpdateAllocation(freeStorageMarker, allocID, lock)
 			if err != nil {
-				log.Fatal("Error free update allocation: ", err)
+				return commandError("Error free update allocation: ", err)
 			}
 			log.Print("Allocation updated with txId : " + txnHash)
-			return
+			return nil
 		}
 
 		var updateTerms = false
 		if flags.Changed("update_terms") {
 			updateTerms, err = flags.GetBool("update_terms")
 			if err != nil {
-				log.Fatal("invalid update terms entry: ", err)
+				return usageError("invalid update terms entry: ", err)
 			}
 		}
 
 		var lockf float64
 		var lock int64
 		if lockf, err = flags.GetFloat64("lock"); err != nil {
-			log.Fatal("error: invalid 'lock' value:", err)
+			return usageError("error: invalid 'lock' value:", err)
 		}
 		lock = zcncore.ConvertToValue(lockf)
 
 		size, err := flags.GetInt64("size")
 		if err != nil {
-			log.Fatal("invalid 'size' flag: ", err)
+			return usageError("invalid 'size' flag: ", err)
 		}
 
 		expiry, err := flags.GetDuration("expiry")
 		if err != nil {
-			log.Fatal("invalid 'expiry' flag: ", err)
+			return usageError("invalid 'expiry' flag: ", err)
 		}
 
 		setImmutable, _ := cmd.Flags().GetBool("set_immutable")
@@ -67,9 +70,10 @@ var updateAllocationCmd = &cobra.Command{
 		txnHash, err := sdk.UpdateAllocation(size,
 			int64(expiry/time.Second), allocID, lock, setImmutable, updateTerms)
 		if err != nil {
-			log.Fatal("Error updating allocation:", err)
+			return commandError("Error updating allocation:", err)
 		}
 		log.Print("Allocation updated with txId : " + txnHash)
+		return nil
 	},
 }
 
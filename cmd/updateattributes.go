@@ -16,7 +16,7 @@ var updateAttributesCmd = &cobra.Command{
 	Short: "update object attributes on blobbers",
 	Long:  `update object attributes on blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			fflags = cmd.Flags()
@@ -31,34 +31,34 @@ var updateAttributesCmd = &cobra.Command{
 		)
 
 		if !fflags.Changed("allocation") {
-			log.Fatal("missing 'allocation' flag")
+			return usageError("missing 'allocation' flag")
 		}
 
 		if !fflags.Changed("remotepath") {
-			log.Fatal("missing 'remotepath' flag")
+			return usageError("missing 'remotepath' flag")
 		}
 
 		if allocID, err = fflags.GetString("allocation"); err != nil {
-			log.Fatalf("parsing 'allocation' flag: %v", err)
+			return usageError("parsing 'allocation' flag:", err)
 		}
 
 		if remotePath, err = fflags.GetString("remotepath"); err != nil {
-			log.Fatalf("paring 'remotepath' flag: %v", err)
+			return usageError("paring 'remotepath' flag:", err)
 		}
 
 		if fflags.Changed("commit") {
 			if commit, err = fflags.GetBool("commit"); err != nil {
-				log.Fatalf("parsing 'commit' flag: %v'", err)
+				return usageError("parsing 'commit' flag:", err)
 			}
 		}
 
 		if alloc, err = sdk.GetAllocation(allocID); err != nil {
-			log.Fatal("fetching the allocation: ", err)
+			return commandError("fetching the allocation: ", err)
 		}
 
 		meta, err := alloc.GetFileMeta(remotePath)
 		if err != nil {
-			log.Fatal("fetching the metadata: ", err)
+			return commandError("fetching the metadata: ", err)
 		}
 
 		var attrs = meta.Attributes
@@ -70,10 +70,10 @@ var updateAttributesCmd = &cobra.Command{
 				wps string
 			)
 			if wps, err = fflags.GetString("who-pays-for-reads"); err != nil {
-				log.Fatalf("getting 'who-pays-for-reads' flag: %v", err)
+				return usageError("getting 'who-pays-for-reads' flag:", err)
 			}
 			if err = wp.Parse(wps); err != nil {
-				log.Fatal(err)
+				return commandError(err)
 			}
 			if wp != attrs.WhoPaysForReads {
 				attrs.WhoPaysForReads, changed = wp, true // change
@@ -82,17 +82,17 @@ var updateAttributesCmd = &cobra.Command{
 
 		if !changed {
 			log.Print("no changes")
-			return
+			return nil
 		}
 
 		if err = alloc.UpdateObjectAttributes(remotePath, attrs); err != nil {
-			log.Fatal("updating file attributes: ", err)
+			return commandError("updating file attributes: ", err)
 		}
 
 		log.Print("attributes updated")
 
 		if !commit {
-			return
+			return nil
 		}
 
 		log.Print("committing changes...")
@@ -104,6 +104,7 @@ var updateAttributesCmd = &cobra.Command{
 		commitMetaTxn(remotePath, "Update attributes", "", "", alloc, meta,
 			statusBar)
 		wg.Wait()
+		return nil
 	},
 }
 
@@ -2,11 +2,16 @@ package cmd
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	thrown "github.com/0chain/errors"
 	"github.com/0chain/gosdk/core/common"
@@ -23,37 +28,32 @@ var createDirCmd = &cobra.Command{
 	Short: "Create directory",
 	Long:  `Create directory`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags()              // fflags is a *flag.FlagSet
 		if !fflags.Changed("allocation") { // check if the flag "path" is set
-			PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-			os.Exit(1)                                      // and return
+			return usageError("Error: allocation flag is missing")
 		}
 		if !fflags.Changed("dirname") {
-			PrintError("Error: dirname flag is missing")
-			os.Exit(1)
+			return usageError("Error: dirname flag is missing")
 		}
 
 		allocationID := cmd.Flag("allocation").Value.String()
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation.", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation.", err)
 		}
 		dirname := cmd.Flag("dirname").Value.String()
 
 		if err != nil {
-			PrintError("CreateDir failed.", err)
-			os.Exit(1)
+			return commandError("CreateDir failed.", err)
 		}
 		err = allocationObj.CreateDir(dirname)
 
 		if err != nil {
-			PrintError("CreateDir failed.", err)
-			os.Exit(1)
+			return commandError("CreateDir failed.", err)
 		}
 
-		return
+		return nil
 	},
 }
 
@@ -63,27 +63,42 @@ var uploadCmd = &cobra.Command{
 	Short: "upload file to blobbers",
 	Long:  `upload file to blobbers`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fflags := cmd.Flags()                      // fflags is a *flag.FlagSet
 		if fflags.Changed("allocation") == false { // check if the flag "path" is set
-			PrintError("Error: allocation flag is missing") // If not, we'll let the user know
-			os.Exit(1)                                      // and return
+			return usageError("Error: allocation flag is missing")
+		}
+
+		resume, _ := cmd.Flags().GetBool("resume")
+		if resume {
+			allocationID := cmd.Flag("allocation").Value.String()
+			allocationObj, err := sdk.GetAllocation(allocationID)
+			if err != nil {
+				return commandError("Error fetching the allocation.", err)
+			}
+			workers, _ := cmd.Flags().GetInt("workers")
+			results, err := resumeUploads(cmd, allocationObj, cmd.Flag("remotepath").Value.String(), workers)
+			if err != nil {
+				return commandError("Upload failed.", err)
+			}
+			if failed := printTransferSummary(results); failed > 0 {
+				return transferFailedError(cmd.Context(), failed)
+			}
+			return nil
 		}
+
 		if fflags.Changed("remotepath") == false {
-			PrintError("Error: remotepath flag is missing")
-			os.Exit(1)
+			return usageError("Error: remotepath flag is missing")
 		}
 
 		if fflags.Changed("localpath") == false {
-			PrintError("Error: localpath flag is missing")
-			os.Exit(1)
+			return usageError("Error: localpath flag is missing")
 		}
 
 		allocationID := cmd.Flag("allocation").Value.String()
 		allocationObj, err := sdk.GetAllocation(allocationID)
 		if err != nil {
-			PrintError("Error fetching the allocation.", err)
-			os.Exit(1)
+			return commandError("Error fetching the allocation.", err)
 		}
 		remotepath := cmd.Flag("remotepath").Value.String()
 		localpath := cmd.Flag("localpath").Value.String()
@@ -104,10 +119,10 @@ var uploadCmd = &cobra.Command{
 				wps string
 			)
 			if wps, err = fflags.GetString("attr-who-pays-for-reads"); err != nil {
-				log.Fatalf("getting 'attr-who-pays-for-reads' flag: %v", err)
+				return usageError("getting 'attr-who-pays-for-reads' flag:", err)
 			}
 			if err = wp.Parse(wps); err != nil {
-				log.Fatal(err)
+				return commandError(err)
 			}
 			attrs.WhoPaysForReads = wp // set given value
 		}
@@ -115,39 +130,98 @@ var uploadCmd = &cobra.Command{
 		live, _ := cmd.Flags().GetBool("live")
 		sync, _ := cmd.Flags().GetBool("sync")
 		chunkSize, _ := cmd.Flags().GetInt("chunksize")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+
+		if localpath == stdioPath && (recursive || live || sync || len(thumbnailpath) > 0) {
+			return usageError("Error: --recursive, --live, --sync and --thumbnailpath can not be used when uploading from stdin")
+		}
+		if !recursive && (cmd.Flags().Changed("include") || cmd.Flags().Changed("exclude")) {
+			return usageError("Error: --include and --exclude only work with --recursive")
+		}
+
+		if recursive {
+			workers, _ := cmd.Flags().GetInt("workers")
+			pathFilter, err := newPathFilter(cmd, localpath)
+			if err != nil {
+				return err
+			}
+			results, err := startRecursiveUpload(cmd, allocationObj, localpath, remotepath, encrypt, chunkSize, attrs, commit, workers, pathFilter)
+			if err != nil {
+				return commandError("Upload failed.", err)
+			}
+			if failed := printTransferSummary(results); failed > 0 {
+				return transferFailedError(cmd.Context(), failed)
+			}
+			return nil
+		}
 
+		var streamHash string
 		if live {
 			// capture video and audio from local default camera and micrlphone, and upload it to zcn
 			err = startLiveUpload(cmd, allocationObj, localpath, remotepath, encrypt, chunkSize, attrs)
 		} else if sync {
 			// download video from remote live feed(eg youtube), and sync it to zcn
 			err = startSyncUpload(cmd, allocationObj, localpath, remotepath, encrypt, chunkSize, attrs)
+		} else if localpath == stdioPath {
+			// stream data piped to zbox, e.g. a database dump
+			mimeType, _ := cmd.Flags().GetString("mimetype")
+			size, _ := cmd.Flags().GetInt64("size")
+			streamHash, err = startStreamUpload(cmd, allocationObj, remotepath, mimeType, size, encrypt, chunkSize, attrs, statusBar)
 		} else {
-			err = startChunkedUpload(cmd, allocationObj, localpath, thumbnailpath, remotepath, encrypt, chunkSize, attrs, statusBar, false)
+			err = startChunkedUpload(cmd.Context(), allocationObj, localpath, thumbnailpath, remotepath, encrypt, chunkSize, attrs, statusBar, false)
 		}
 
 		if err != nil {
-			PrintError("Upload failed.", err)
-			os.Exit(1)
+			return transferError(cmd.Context(), "Upload failed.", err)
 		}
 		wg.Wait()
 		if !statusBar.success {
-			os.Exit(1)
+			return transferError(cmd.Context(), "Upload failed.", statusBar.err)
 		}
 
 		if commit {
 			remotepath = zboxutil.GetFullRemotePath(localpath, remotepath)
 			statusBar.wg.Add(1)
-			commitMetaTxn(remotepath, "Upload", "", "", allocationObj, nil, statusBar)
+			if err := commitMetaTxn(remotepath, "Upload", "", "", allocationObj, nil, statusBar); err != nil {
+				return err
+			}
 			statusBar.wg.Wait()
 		}
 
-		return
+		if verify, _ := cmd.Flags().GetBool("verify"); verify && !live && !sync {
+			localHash := streamHash
+			if localpath != stdioPath {
+				localHash, err = computeFileHash(localpath, int64(chunkSize), allocationObj.DataShards, encrypt)
+				if err != nil {
+					return commandError("Error computing hash of", localpath, err)
+				}
+			}
+			remotepath = zboxutil.GetFullRemotePath(localpath, zboxutil.RemoteClean(remotepath))
+			if err = verifyUpload(allocationObj, remotepath, localHash); err != nil {
+				return commandError(err)
+			}
+			fmt.Println("Integrity verified:", remotepath)
+		}
+
+		return nil
 	},
 }
 
-func startChunkedUpload(cmd *cobra.Command, allocationObj *sdk.Allocation, localPath, thumbnailPath, remotePath string, encrypt bool, chunkSize int, attrs fileref.Attributes, statusBar sdk.StatusCallback, isUpdate bool) error {
+// verifyUpload checks localHash against the hash blobbers hold for remotePath
+func verifyUpload(allocationObj *sdk.Allocation, remotePath, localHash string) error {
+	meta, err := allocationObj.GetFileMeta(remotePath)
+	if err != nil {
+		return err
+	}
+	return checkIntegrity(remotePath, localHash, meta.Hash)
+}
+
+func startChunkedUpload(ctx context.Context, allocationObj *sdk.Allocation, localPath, thumbnailPath, remotePath string, encrypt bool, chunkSize int, attrs fileref.Attributes, statusBar sdk.StatusCallback, isUpdate bool) error {
 
+	// the sdk keys the progress of the upload by the local path, which must be the same when it is resumed from another directory
+	if absPath, err := filepath.Abs(localPath); err == nil {
+		localPath = absPath
+	}
 	fileReader, err := os.Open(localPath)
 	if err != nil {
 		return err
@@ -183,16 +257,209 @@ func startChunkedUpload(cmd *cobra.Command, allocationObj *sdk.Allocation, local
 		Attributes: attrs,
 	}
 
-	ChunkedUpload, err := sdk.CreateChunkedUpload(util.GetHomeDir(), allocationObj, fileMeta, fileReader, isUpdate,
+	checkpoint := &uploadCheckpoint{StatusCallback: statusBar}
+	ChunkedUpload, err := sdk.CreateChunkedUpload(util.GetHomeDir(), allocationObj, fileMeta, &contextReader{ctx: ctx, r: fileReader}, isUpdate,
 		sdk.WithThumbnailFile(thumbnailPath),
 		sdk.WithChunkSize(int64(chunkSize)),
 		sdk.WithEncrypt(encrypt),
-		sdk.WithStatusCallback(statusBar))
+		sdk.WithStatusCallback(checkpoint))
 	if err != nil {
 		return err
 	}
 
-	return ChunkedUpload.Start()
+	// keep what is needed to resume the upload with upload --resume
+	record := &uploadRecord{
+		ID:           uploadProgressID(allocationObj.ID, fileMeta),
+		AllocationID: allocationObj.ID,
+		LocalPath:    localPath,
+		RemotePath:   remotePath,
+		Size:         fileInfo.Size(),
+		ChunkSize:    chunkSize,
+		Encrypt:      encrypt,
+		IsUpdate:     isUpdate,
+		Attributes:   attrs,
+		StartedAt:    time.Now().Unix(),
+	}
+	if err = saveUploadRecord(record); err != nil {
+		PrintError("Warning: failed to save upload progress.", err)
+	}
+
+	err = ChunkedUpload.Start()
+	if err == nil {
+		removeUpload(record.ID)
+	} else if ctx.Err() != nil {
+		checkpoint.wait(filepath.Join(uploadProgressDir(), record.ID))
+	}
+	return err
+}
+
+// uploadProgressWriteTimeout bounds how long an interrupted upload waits for the sdk to write its progress,
+// which the sdk does every second
+const uploadProgressWriteTimeout = 3 * time.Second
+
+// uploadCheckpoint is the status callback of a chunked upload. It notes when the sdk last saved the progress
+// of an uploaded chunk, which the sdk reports right after saving it.
+type uploadCheckpoint struct {
+	sdk.StatusCallback
+	mu    sync.Mutex
+	saved time.Time
+}
+
+func (c *uploadCheckpoint) InProgress(allocationID, filePath string, op int, completedBytes int, data []byte) {
+	c.mu.Lock()
+	c.saved = time.Now()
+	c.mu.Unlock()
+	if c.StatusCallback != nil {
+		c.StatusCallback.InProgress(allocationID, filePath, op, completedBytes, data)
+	}
+}
+
+// wait waits until the sdk has written the progress it saved last to progressPath, so a stopped upload
+// resumes after its last uploaded chunk. The write is done once the file is modified after and holds all of the json.
+func (c *uploadCheckpoint) wait(progressPath string) {
+	c.mu.Lock()
+	saved := c.saved
+	c.mu.Unlock()
+	if saved.IsZero() {
+		return
+	}
+	for deadline := time.Now().Add(uploadProgressWriteTimeout); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		fi, err := os.Stat(progressPath)
+		if err != nil || !fi.ModTime().After(saved) {
+			continue
+		}
+		if buf, err := ioutil.ReadFile(progressPath); err == nil && json.Valid(buf) {
+			return
+		}
+	}
+}
+
+// contextReader stops reading once ctx is done. The sdk reads the next chunk once the chunk in flight is uploaded,
+// so chunked uploads stop between chunks.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// startRecursiveUpload walks localPath and uploads every file in it under remotePath, recreating the directory tree on the allocation.
+// Files and directories skipped by pathFilter are not uploaded.
+func startRecursiveUpload(cmd *cobra.Command, allocationObj *sdk.Allocation, localPath, remotePath string, encrypt bool, chunkSize int, attrs fileref.Attributes, commit bool, workers int, pathFilter *pathFilter) ([]transferResult, error) {
+
+	fileInfo, err := os.Stat(localPath)
+	if err != nil {
+		return nil, err
+	}
+	if !fileInfo.IsDir() {
+		return nil, thrown.New("invalid_path", "localpath should be a directory when --recursive is used")
+	}
+
+	remotePath = zboxutil.RemoteClean(remotePath)
+	isabs := zboxutil.IsRemoteAbs(remotePath)
+	if !isabs {
+		return nil, thrown.New("invalid_path", "Path should be valid and absolute")
+	}
+
+	var (
+		failed []transferResult
+		tasks  []transferTask
+	)
+	err = filepath.Walk(localPath, func(lPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			failed = append(failed, transferResult{LocalPath: lPath, Err: err})
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(localPath, lPath)
+		if err != nil {
+			return err
+		}
+		if pathFilter.Skip(rel, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rPath := path.Join(remotePath, filepath.ToSlash(rel))
+
+		if info.IsDir() {
+			if rPath != "/" {
+				// the directory may already exist on the blobbers, uploads below create it implicitly anyway
+				if err := allocationObj.CreateDir(rPath); err != nil {
+					PrintError("Warning: creating remote directory", rPath, "failed.", err)
+				}
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		fileEncrypt := encrypt || strings.HasPrefix(rPath, "/Encrypted")
+		tasks = append(tasks, transferTask{
+			LocalPath:  lPath,
+			RemotePath: rPath,
+			Size:       info.Size(),
+			Run: func(status *transferStatus) error {
+				return uploadFile(cmd, allocationObj, lPath, rPath, fileEncrypt, chunkSize, attrs, commit, false, status)
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := newTransferScheduler(cmd.Context(), workers).Run(tasks)
+	return append(failed, results...), nil
+}
+
+// uploadFile uploads a single local file to remotePath and waits until blobbers confirm it
+func uploadFile(cmd *cobra.Command, allocationObj *sdk.Allocation, localPath, remotePath string, encrypt bool, chunkSize int, attrs fileref.Attributes, commit, isUpdate bool, status *transferStatus) error {
+	status.wg.Add(1)
+	err := startChunkedUpload(cmd.Context(), allocationObj, localPath, "", remotePath, encrypt, chunkSize, attrs, status, isUpdate)
+	if err != nil {
+		return err
+	}
+	if err = status.wait(); err != nil {
+		return err
+	}
+
+	if commit {
+		crudOp := "Upload"
+		if isUpdate {
+			crudOp = "Update"
+		}
+		status.wg.Add(1)
+		err = allocationObj.CommitMetaTransaction(remotePath, crudOp, "", "", nil, status)
+		if err != nil {
+			return err
+		}
+		if err = status.wait(); err != nil {
+			return err
+		}
+	}
+
+	// commands without --verify, like sync, never verify
+	if verify, _ := cmd.Flags().GetBool("verify"); verify {
+		localHash, err := computeFileHash(localPath, int64(chunkSize), allocationObj.DataShards, encrypt)
+		if err != nil {
+			return err
+		}
+		return verifyUpload(allocationObj, remotePath, localHash)
+	}
+
+	return nil
 }
 
 func startLiveUpload(cmd *cobra.Command, allocationObj *sdk.Allocation, localPath string, remotePath string, encrypt bool, chunkSize int, attrs fileref.Attributes) error {
@@ -254,7 +521,7 @@ func startSyncUpload(cmd *cobra.Command, allocationObj *sdk.Allocation, localPat
 		return thrown.New("invalid_path", "feed should be valid")
 	}
 
-	reader, err := sdk.CreateYoutubeDL(sdk.NewSignalContext(context.TODO()), localPath, feed, util.SplitArgs(downloadArgs), util.SplitArgs(ffmpegArgs), delay)
+	reader, err := sdk.CreateYoutubeDL(cmd.Context(), localPath, feed, util.SplitArgs(downloadArgs), util.SplitArgs(ffmpegArgs), delay)
 	if err != nil {
 		return err
 	}
@@ -303,13 +570,20 @@ func init() {
 	rootCmd.AddCommand(createDirCmd)
 	uploadCmd.PersistentFlags().String("allocation", "", "Allocation ID")
 	uploadCmd.PersistentFlags().String("remotepath", "", "Remote path to upload")
-	uploadCmd.PersistentFlags().String("localpath", "", "Local path of file to upload")
+	uploadCmd.PersistentFlags().String("localpath", "", "Local path of file to upload, - to upload from stdin")
 	uploadCmd.PersistentFlags().String("thumbnailpath", "", "Local thumbnail path of file to upload")
 	uploadCmd.PersistentFlags().String("attr-who-pays-for-reads", "owner", "Who pays for reads: owner or 3rd_party")
 	uploadCmd.Flags().Bool("encrypt", false, "pass this option to encrypt and upload the file")
 	uploadCmd.Flags().Bool("commit", false, "pass this option to commit the metadata transaction")
+	uploadCmd.Flags().Bool("recursive", false, "pass this option to upload every file in --localpath directory to --remotepath directory")
+	uploadCmd.Flags().Int("workers", defaultTransferWorkers, "number of files uploaded in parallel. only works with --recursive and --resume.")
+	addFilterFlags(uploadCmd)
+	uploadCmd.Flags().Bool("verify", false, "pass this option to compare the hash of the local file with the hash blobbers hold for it once uploaded")
+	uploadCmd.Flags().Bool("resume", false, "pass this option to resume interrupted uploads of the allocation, or only the one of --remotepath. see upload-status.")
 
 	uploadCmd.Flags().Int("chunksize", sdk.CHUNK_SIZE, "chunk size")
+	uploadCmd.Flags().Int64("size", 0, "bytes to read from stdin, optional. only works with --localpath -.")
+	uploadCmd.Flags().String("mimetype", "", "mime type of the uploaded data, detected from its first bytes if not set. only works with --localpath -.")
 
 	uploadCmd.Flags().Int("delay", 5, "set segment duration to seconds. only works with --live and --sync. default duration is 5s.")
 
@@ -323,8 +597,6 @@ func init() {
 	uploadCmd.Flags().Bool("live", false, "enable LiveUpload from local devices. disabled by default.")
 
 	uploadCmd.MarkFlagRequired("allocation")
-	uploadCmd.MarkFlagRequired("remotepath")
-	uploadCmd.MarkFlagRequired("localpath")
 
 	createDirCmd.PersistentFlags().String("allocation", "", "Allocation ID")
 	createDirCmd.PersistentFlags().String("dirname", "", "New directory name")
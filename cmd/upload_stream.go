@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	thrown "github.com/0chain/errors"
+	"github.com/0chain/gosdk/zboxcore/fileref"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/0chain/gosdk/zboxcore/zboxutil"
+	"github.com/0chain/zboxcli/util"
+	"github.com/h2non/filetype"
+	"github.com/spf13/cobra"
+)
+
+// stdioPath is the localpath which makes upload read from stdin, and download write to stdout
+const stdioPath = "-"
+
+// startStreamUpload uploads everything read from stdin to remotePath, and returns the hash of what was read.
+// size is 0 if it is unknown, and mimeType is detected from the first bytes of the stream if it is empty.
+// A stream can not be read twice, so its upload can not be resumed once it is interrupted.
+func startStreamUpload(cmd *cobra.Command, allocationObj *sdk.Allocation, remotePath, mimeType string, size int64, encrypt bool, chunkSize int, attrs fileref.Attributes, statusBar sdk.StatusCallback) (string, error) {
+
+	remotePath = zboxutil.RemoteClean(remotePath)
+	isabs := zboxutil.IsRemoteAbs(remotePath)
+	if !isabs {
+		return "", thrown.New("invalid_path", "Path should be valid and absolute")
+	}
+	_, fileName := filepath.Split(remotePath)
+	if len(fileName) == 0 || strings.HasSuffix(remotePath, "/") {
+		return "", thrown.New("invalid_path", "remotepath should include the file name when uploading from stdin")
+	}
+
+	// 261 bytes are enough for filetype to match any known type
+	reader := bufio.NewReaderSize(os.Stdin, 1024)
+	if len(mimeType) == 0 {
+		head, err := reader.Peek(261)
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		mimeType = "application/octet-stream"
+		if kind, _ := filetype.Match(head); kind != filetype.Unknown {
+			mimeType = kind.MIME.Value
+		}
+	}
+
+	var fileReader io.Reader = reader
+	if size > 0 {
+		fileReader = &sizedReader{r: reader, size: size}
+	}
+	hasher := newMerkleHasher(int64(chunkSize), allocationObj.DataShards, encrypt)
+	fileReader = io.TeeReader(fileReader, hasher)
+
+	fileMeta := sdk.FileMeta{
+		Path:       stdioPath,
+		ActualSize: size,
+		MimeType:   mimeType,
+		RemoteName: fileName,
+		RemotePath: remotePath,
+		Attributes: attrs,
+	}
+
+	// the progress of an earlier stream to the same remotePath would make the sdk skip the first chunks
+	progressID := uploadProgressID(allocationObj.ID, fileMeta)
+	if err := removeUpload(progressID); err != nil {
+		return "", err
+	}
+	defer removeUpload(progressID)
+
+	checkpoint := &uploadCheckpoint{StatusCallback: statusBar}
+	ChunkedUpload, err := sdk.CreateChunkedUpload(util.GetHomeDir(), allocationObj, fileMeta, &contextReader{ctx: cmd.Context(), r: &fullReader{r: fileReader}}, false,
+		sdk.WithChunkSize(int64(chunkSize)),
+		sdk.WithEncrypt(encrypt),
+		sdk.WithStatusCallback(checkpoint))
+	if err != nil {
+		return "", err
+	}
+
+	err = ChunkedUpload.Start()
+	if err != nil {
+		if cmd.Context().Err() != nil {
+			// let the sdk finish writing progress before it is removed
+			checkpoint.wait(filepath.Join(uploadProgressDir(), progressID))
+		}
+		return "", err
+	}
+	return hasher.Hash()
+}
+
+// fullReader fills the whole buffer on every Read unless the stream ends.
+// The sdk treats a short read as the last chunk, while pipes return whatever is available.
+type fullReader struct {
+	r io.Reader
+}
+
+func (r *fullReader) Read(p []byte) (int, error) {
+	n, err := io.ReadFull(r.r, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// sizedReader reads exactly size bytes, and fails if the stream ends before
+type sizedReader struct {
+	r    io.Reader
+	size int64
+	read int64
+}
+
+func (r *sizedReader) Read(p []byte) (int, error) {
+	if r.read >= r.size {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.size-r.read {
+		p = p[:r.size-r.read]
+	}
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	if err == io.EOF && r.read < r.size {
+		return n, fmt.Errorf("stdin ended after %d of %d bytes", r.read, r.size)
+	}
+	return n, err
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUploadCheckpointWaitsForProgress(t *testing.T) {
+	progressPath := filepath.Join(t.TempDir(), "progress")
+
+	checkpoint := &uploadCheckpoint{}
+	start := time.Now()
+	checkpoint.wait(progressPath)
+	if time.Since(start) > time.Second {
+		t.Error("waited for the progress of an upload which uploaded no chunk")
+	}
+
+	if err := ioutil.WriteFile(progressPath, []byte(`{"chunk_index":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	checkpoint.InProgress("allocation", "/file", 0, 2, nil)
+	// the sdk writes the progress of the last chunk on its next tick
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		ioutil.WriteFile(progressPath, []byte(`{"chunk_index":2}`), 0644)
+	}()
+	checkpoint.wait(progressPath)
+	buf, _ := ioutil.ReadFile(progressPath)
+	if string(buf) != `{"chunk_index":2}` {
+		t.Errorf("wait returned with the progress %s, not the one of the last chunk", buf)
+	}
+}
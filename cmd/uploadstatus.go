@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0chain/gosdk/core/common"
+	"github.com/0chain/gosdk/zboxcore/fileref"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/0chain/zboxcli/util"
+	"github.com/spf13/cobra"
+)
+
+// uploadRecordExt is appended to the sdk progress file name to keep the parameters the upload was started with
+const uploadRecordExt = ".zbox.json"
+
+// uploadRecord parameters of an in-progress chunked upload, saved next to the progress of the sdk
+type uploadRecord struct {
+	ID           string             `json:"id"`
+	AllocationID string             `json:"allocation_id"`
+	LocalPath    string             `json:"local_path"`
+	RemotePath   string             `json:"remote_path"`
+	Size         int64              `json:"size"`
+	ChunkSize    int                `json:"chunk_size"`
+	Encrypt      bool               `json:"encrypt"`
+	IsUpdate     bool               `json:"is_update"`
+	Attributes   fileref.Attributes `json:"attributes"`
+	StartedAt    int64              `json:"started_at"`
+
+	// Uploaded bytes confirmed by blobbers, loaded from the sdk progress
+	Uploaded int64 `json:"uploaded"`
+}
+
+// uploadProgressDir is where sdk.CreateChunkedUpload keeps the progress of uploads started by zbox
+func uploadProgressDir() string {
+	return filepath.Join(util.GetHomeDir(), ".zcn", "upload")
+}
+
+// uploadProgressID builds the same progress id as the sdk does for a chunked upload
+func uploadProgressID(allocationID string, fileMeta sdk.FileMeta) string {
+	if len(allocationID) > 8 {
+		allocationID = allocationID[:8]
+	}
+	return allocationID + "_" + fileMeta.FileID()
+}
+
+func saveUploadRecord(r *uploadRecord) error {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(uploadProgressDir(), 0744); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(uploadProgressDir(), r.ID+uploadRecordExt), buf, 0644)
+}
+
+// removeUpload discards both the sdk progress and the zbox record of an upload
+func removeUpload(id string) error {
+	for _, name := range []string{id, id + uploadRecordExt} {
+		if err := os.Remove(filepath.Join(uploadProgressDir(), name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadUploadRecords lists every in-progress upload found in uploadProgressDir
+func loadUploadRecords() ([]*uploadRecord, error) {
+	files, err := ioutil.ReadDir(uploadProgressDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	records := make(map[string]*uploadRecord)
+	for _, f := range files {
+		if f.IsDir() || strings.HasSuffix(f.Name(), uploadRecordExt) {
+			continue
+		}
+		// progress of an upload started by another client of the sdk, only its id is known
+		id := f.Name()
+		records[id] = &uploadRecord{ID: id, AllocationID: strings.SplitN(id, "_", 2)[0]}
+	}
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), uploadRecordExt) {
+			continue
+		}
+		buf, err := ioutil.ReadFile(filepath.Join(uploadProgressDir(), f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		r := &uploadRecord{}
+		if err = json.Unmarshal(buf, r); err != nil {
+			PrintError("Invalid upload record", f.Name(), err)
+			continue
+		}
+		records[r.ID] = r
+	}
+
+	list := make([]*uploadRecord, 0, len(records))
+	for _, r := range records {
+		r.Uploaded = loadUploadedBytes(filepath.Join(uploadProgressDir(), r.ID))
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartedAt < list[j].StartedAt
+	})
+	return list, nil
+}
+
+// loadUploadedBytes reads how many bytes of the original file blobbers have confirmed from the sdk progress
+func loadUploadedBytes(progressPath string) int64 {
+	buf, err := ioutil.ReadFile(progressPath)
+	if err != nil {
+		return 0
+	}
+	var progress struct {
+		Blobbers []struct {
+			UploadLength int64 `json:"upload_length"`
+		} `json:"merkle_hashers"`
+	}
+	if err = json.Unmarshal(buf, &progress); err != nil {
+		return 0
+	}
+	var uploaded int64
+	for _, b := range progress.Blobbers {
+		uploaded = maxInt64(uploaded, b.UploadLength)
+	}
+	return uploaded
+}
+
+// filterUploadRecords returns records of allocationID, and of remotePath when it is given
+func filterUploadRecords(records []*uploadRecord, allocationID, remotePath string) []*uploadRecord {
+	var filtered []*uploadRecord
+	for _, r := range records {
+		if len(allocationID) > 0 && r.AllocationID != allocationID && !strings.HasPrefix(allocationID, r.AllocationID) {
+			continue
+		}
+		if len(remotePath) > 0 && r.RemotePath != remotePath {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	return filtered
+}
+
+// uploadStatusCmd lists in-progress chunked uploads
+var uploadStatusCmd = &cobra.Command{
+	Use:   "upload-status",
+	Short: "List in-progress uploads",
+	Long: `List chunked uploads which were interrupted before they completed.
+Use upload --resume to continue them, or upload-abort to discard them.`,
+	Annotations: map[string]string{annotationOffline: "true", annotationAllocationFilter: "true"},
+	Args:        cobra.MinimumNArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		allocationID, _ := cmd.Flags().GetString("allocation")
+
+		records, err := loadUploadRecords()
+		if err != nil {
+			return commandError("Error reading upload progress.", err)
+		}
+		records = filterUploadRecords(records, allocationID, "")
+
+		if len(records) == 0 && getOutputFormat(cmd) == outputTable {
+			fmt.Println("No uploads in progress")
+			return nil
+		}
+
+		header := []string{"ID", "Allocation", "Local Path", "Remote Path", "Uploaded", "Started"}
+		data := make([][]string, len(records))
+		for idx, r := range records {
+			uploaded := common.Size(r.Uploaded).String()
+			if r.Size > 0 {
+				uploaded = fmt.Sprintf("%s / %s (%d%%)", common.Size(r.Uploaded), common.Size(r.Size), r.Uploaded*100/r.Size)
+			}
+			started := ""
+			if r.StartedAt > 0 {
+				started = time.Unix(r.StartedAt, 0).String()
+			}
+			data[idx] = []string{r.ID, r.AllocationID, r.LocalPath, r.RemotePath, uploaded, started}
+		}
+		csvHeader := []string{"id", "allocation_id", "local_path", "remote_path", "size", "uploaded", "started_at"}
+		csvData := make([][]string, len(records))
+		for idx, r := range records {
+			csvData[idx] = []string{r.ID, r.AllocationID, r.LocalPath, r.RemotePath,
+				strconv.FormatInt(r.Size, 10), strconv.FormatInt(r.Uploaded, 10), strconv.FormatInt(r.StartedAt, 10)}
+		}
+		printOutput(cmd, records, csvHeader, csvData, func() {
+			util.WriteTable(os.Stdout, header, []string{}, data)
+		})
+		return nil
+	},
+}
+
+// uploadAbortCmd discards in-progress chunked uploads
+var uploadAbortCmd = &cobra.Command{
+	Use:   "upload-abort",
+	Short: "Discard in-progress uploads",
+	Long: `Discard the local progress of interrupted chunked uploads, so the next upload of
+the same file starts from scratch.`,
+	Annotations: map[string]string{annotationOffline: "true", annotationAllocationFilter: "true"},
+	Args:        cobra.MinimumNArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fflags := cmd.Flags()
+		id, _ := fflags.GetString("id")
+		allocationID, _ := fflags.GetString("allocation")
+		remotePath, _ := fflags.GetString("remotepath")
+		all, _ := fflags.GetBool("all")
+
+		if len(id) == 0 && len(allocationID) == 0 && !all {
+			return usageError("Error: id / allocation / all flag is missing")
+		}
+
+		records, err := loadUploadRecords()
+		if err != nil {
+			return commandError("Error reading upload progress.", err)
+		}
+		if len(id) > 0 {
+			var found []*uploadRecord
+			for _, r := range records {
+				if r.ID == id {
+					found = append(found, r)
+				}
+			}
+			records = found
+		} else if !all {
+			records = filterUploadRecords(records, allocationID, remotePath)
+		}
+
+		if len(records) == 0 {
+			return notFoundError("No matching uploads in progress")
+		}
+		for _, r := range records {
+			if err := removeUpload(r.ID); err != nil {
+				return commandError("Error discarding upload", r.ID, err)
+			}
+			fmt.Println("Upload aborted:", r.ID, r.RemotePath)
+		}
+		return nil
+	},
+}
+
+// resumeUploads continues every recorded upload of allocationObj, or only the one of remotePath when it is given
+func resumeUploads(cmd *cobra.Command, allocationObj *sdk.Allocation, remotePath string, workers int) ([]transferResult, error) {
+	records, err := loadUploadRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []transferTask
+	for _, r := range filterUploadRecords(records, allocationObj.ID, remotePath) {
+		if len(r.LocalPath) == 0 {
+			PrintError("Skipping upload " + r.ID + ", it was not started by zbox")
+			continue
+		}
+		r := r
+		tasks = append(tasks, transferTask{
+			LocalPath:  r.LocalPath,
+			RemotePath: r.RemotePath,
+			Size:       r.Size - r.Uploaded,
+			Run: func(status *transferStatus) error {
+				if err := uploadFile(cmd, allocationObj, r.LocalPath, r.RemotePath, r.Encrypt, r.ChunkSize, r.Attributes, false, r.IsUpdate, status); err != nil {
+					return err
+				}
+				// a record saved with another local path than the resumed upload has another id, it is stale once the upload is done
+				if err := removeUpload(r.ID); err != nil {
+					PrintError("Warning: failed to remove upload progress of "+r.ID+".", err)
+				}
+				return nil
+			},
+		})
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("no uploads in progress for allocation %s", allocationObj.ID)
+	}
+
+	return newTransferScheduler(cmd.Context(), workers).Run(tasks), nil
+}
+
+func init() {
+	rootCmd.AddCommand(uploadStatusCmd)
+	uploadStatusCmd.PersistentFlags().String("allocation", "", "Allocation ID, list uploads of all allocations if it is not set")
+	uploadStatusCmd.Flags().Bool("json", false, "pass this option to print response as json data, same as --output json")
+
+	rootCmd.AddCommand(uploadAbortCmd)
+	uploadAbortCmd.PersistentFlags().String("id", "", "ID of the upload as listed by upload-status")
+	uploadAbortCmd.PersistentFlags().String("allocation", "", "Allocation ID, discard all uploads of this allocation")
+	uploadAbortCmd.PersistentFlags().String("remotepath", "", "Remote path of the upload to discard, only works with --allocation")
+	uploadAbortCmd.Flags().Bool("all", false, "pass this option to discard all uploads in progress")
+}
@@ -10,14 +10,15 @@ import (
 var VersionStr string
 
 var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Prints version information",
-	Long:  `Prints version information`,
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:         "version",
+	Short:       "Prints version information",
+	Long:        `Prints version information`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("Version info:")
 		fmt.Println("\tzbox....: ", VersionStr)
 		fmt.Println("\tgosdk...: ", zcncore.GetVersion())
-		return
+		return nil
 	},
 }
 
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/0chain/gosdk/core/conf"
+	"github.com/0chain/gosdk/core/zcncrypto"
+	"github.com/0chain/gosdk/zboxcore/encryption"
+	"github.com/0chain/gosdk/zboxcore/sdk"
+	"github.com/0chain/gosdk/zcncore"
+	"github.com/0chain/zboxcli/util"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// walletCmd represents the wallet command group
+var walletCmd = &cobra.Command{
+	Use:         "wallet",
+	Short:       "Manage the wallet file",
+	Long:        `Manage the wallet file, by default wallet.json of the active profile or --wallet`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+// walletFileOf returns the wallet file commands of walletCmd work on, and its content
+func walletFileOf() (string, []byte, error) {
+	configDir, err := resolveConfigDir()
+	if err != nil {
+		return "", nil, configError("Error:", err)
+	}
+	path := getWalletFilePath(configDir)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", nil, configError("Error reading the wallet:", err)
+	}
+	return path, data, nil
+}
+
+var walletEncryptFileCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the wallet file with a passphrase",
+	Long: `Encrypt the wallet file with a passphrase, read from --wallet_passphrase_fd, $ZBOX_WALLET_PASSPHRASE or a prompt.
+Commands then decrypt the wallet in memory only, and ask for the passphrase the same way.`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, data, err := walletFileOf()
+		if err != nil {
+			return err
+		}
+		if isEncryptedWallet(data) {
+			return commandError("Error: " + path + " is already encrypted")
+		}
+		// do not encrypt something which can not be read back as a wallet
+		wallet := &zcncrypto.Wallet{}
+		if err := json.Unmarshal(data, wallet); err != nil || len(wallet.ClientID) == 0 {
+			return configError("Error: invalid wallet at path:" + path)
+		}
+
+		passphrase, err := getWalletPassphrase("New passphrase: ", true)
+		if err != nil {
+			return configError("Error:", err)
+		}
+		if err = writeWalletFile(path, string(data), passphrase); err != nil {
+			return commandError("Error writing the wallet:", err)
+		}
+		fmt.Println("Wallet encrypted:", path)
+		return nil
+	},
+}
+
+var walletDecryptFileCmd = &cobra.Command{
+	Use:         "decrypt",
+	Short:       "Decrypt the wallet file, storing it in plain text",
+	Long:        `Decrypt an encrypted wallet file, and store it in plain text again`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, data, err := walletFileOf()
+		if err != nil {
+			return err
+		}
+		if !isEncryptedWallet(data) {
+			return commandError("Error: " + path + " is not encrypted")
+		}
+		passphrase, err := getWalletPassphrase("Passphrase of "+path+": ", false)
+		if err != nil {
+			return configError("Error:", err)
+		}
+		walletJSON, err := decryptWallet(data, passphrase)
+		if err != nil {
+			return configError("Error:", err)
+		}
+		if err = writeWalletFile(path, walletJSON, ""); err != nil {
+			return commandError("Error writing the wallet:", err)
+		}
+		fmt.Println("Wallet decrypted:", path)
+		return nil
+	},
+}
+
+var walletCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a new wallet and register it with the blockchain",
+	Long: `Create a new wallet, register it with the blockchain, and save it to the wallet file, which must not exist.
+The wallet is encrypted with --encrypt, or when a passphrase is given by --wallet_passphrase_fd or $ZBOX_WALLET_PASSPHRASE.
+The read pool of the wallet is created too with --read_pool, see rp-create.`,
+	// the sdk is initialized by the command, as there is no wallet yet
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fflags := cmd.Flags()
+		configDir, err := resolveConfigDir()
+		if err != nil {
+			return configError("Error:", err)
+		}
+		path := getWalletFilePath(configDir)
+		if fileExists(path) {
+			return commandError("Error: a wallet already exists at " + path + ", use --wallet or --profile to create another one")
+		}
+
+		// read the passphrase first, so no wallet is registered if there is none
+		var passphrase string
+		if encrypt, _ := fflags.GetBool("encrypt"); encrypt || walletPassphraseSupplied() {
+			if passphrase, err = getWalletPassphrase("New passphrase: ", true); err != nil {
+				return configError("Error:", err)
+			}
+		}
+
+		cfg, network, err := initCoreSDK(configDir)
+		if err != nil {
+			return err
+		}
+		wg := &sync.WaitGroup{}
+		statusBar := &ZCNStatus{wg: wg}
+		wg.Add(1)
+		if err = zcncore.CreateWallet(statusBar); err != nil {
+			return commandError("Error creating the wallet.", err)
+		}
+		wg.Wait()
+		if len(statusBar.walletString) == 0 || !statusBar.success {
+			return commandError("Error creating the wallet." + statusBar.errMsg)
+		}
+
+		walletJSON = statusBar.walletString
+		if err = writeWalletFile(path, walletJSON, passphrase); err != nil {
+			return commandError("Error writing the wallet:", err)
+		}
+		wallet := &zcncrypto.Wallet{}
+		if err = json.Unmarshal([]byte(walletJSON), wallet); err != nil {
+			return commandError("Error: invalid wallet created:", err)
+		}
+		clientWallet = wallet
+		if len(passphrase) > 0 {
+			fmt.Println("ZCN wallet created, encrypted with the passphrase:", path)
+		} else {
+			fmt.Println("ZCN wallet created:", path)
+		}
+		fmt.Println("ClientID:", wallet.ClientID)
+
+		if readPool, _ := fflags.GetBool("read_pool"); readPool {
+			if err = initStorageSDK(cfg, network); err != nil {
+				return err
+			}
+			fmt.Println("Creating related read pool for storage smart-contract...")
+			if err = sdk.CreateReadPool(); err != nil {
+				return commandError("Failed to create read pool:", err)
+			}
+			fmt.Println("Read pool created successfully")
+		}
+		return nil
+	},
+}
+
+var walletRecoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Recover a wallet from its mnemonic",
+	Long: `Recover the keys of a wallet from its mnemonic, and save them to the wallet file, which must not exist.
+The mnemonic is prompted for if --mnemonic is not set. The wallet is encrypted with --encrypt,
+or when a passphrase is given by --wallet_passphrase_fd or $ZBOX_WALLET_PASSPHRASE.
+The wallet is not registered, run zbox register if the network does not know it.`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fflags := cmd.Flags()
+		configDir, err := resolveConfigDir()
+		if err != nil {
+			return configError("Error:", err)
+		}
+		// the keys derived from a mnemonic depend on the signature scheme of the network
+		cfg, err := conf.LoadConfigFile(getConfigFilePath(configDir))
+		if err != nil {
+			return configError("Can't read config:", err)
+		}
+		if cfg.SignatureScheme != "bls0chain" && cfg.SignatureScheme != "ed25519" {
+			return configError("Error: unknown signature_scheme " + cfg.SignatureScheme + " in config")
+		}
+
+		path := getWalletFilePath(configDir)
+		if fileExists(path) {
+			return commandError("Error: a wallet already exists at " + path + ", use --wallet or --profile to recover to another file")
+		}
+
+		mnemonic, _ := fflags.GetString("mnemonic")
+		if len(mnemonic) == 0 {
+			if !term.IsTerminal(int(os.Stdin.Fd())) {
+				return usageError("Error: --mnemonic is required when stdin is not a terminal")
+			}
+			if mnemonic, err = promptPassphrase("Mnemonic: "); err != nil {
+				return commandError("Error:", err)
+			}
+		}
+		mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+		if !zcncrypto.IsMnemonicValid(mnemonic) {
+			return usageError("Error: invalid mnemonic")
+		}
+
+		wallet, err := zcncrypto.NewSignatureScheme(cfg.SignatureScheme).RecoverKeys(mnemonic)
+		if err != nil {
+			return commandError("Error recovering the wallet:", err)
+		}
+		walletJSON, err := wallet.Marshal()
+		if err != nil {
+			return commandError("Error recovering the wallet:", err)
+		}
+
+		var passphrase string
+		if encrypt, _ := fflags.GetBool("encrypt"); encrypt || walletPassphraseSupplied() {
+			if passphrase, err = getWalletPassphrase("New passphrase: ", true); err != nil {
+				return configError("Error:", err)
+			}
+		}
+		if err = writeWalletFile(path, walletJSON, passphrase); err != nil {
+			return commandError("Error writing the wallet:", err)
+		}
+		fmt.Println("Wallet recovered:", path)
+		fmt.Println("ClientID:", wallet.ClientID)
+		return nil
+	},
+}
+
+var walletExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the mnemonic and public keys of the wallet",
+	Long: `Print the mnemonic and public keys of the wallet, to back it up or to recover it with zbox wallet recover.
+Anyone who knows the mnemonic controls the wallet, so it is only printed once confirmed, or with --yes.`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, data, err := walletFileOf()
+		if err != nil {
+			return err
+		}
+		walletJSON := string(data)
+		if isEncryptedWallet(data) {
+			passphrase, err := getWalletPassphrase("Passphrase of "+path+": ", false)
+			if err != nil {
+				return configError("Error:", err)
+			}
+			if walletJSON, err = decryptWallet(data, passphrase); err != nil {
+				return configError("Error:", err)
+			}
+		}
+		wallet := &zcncrypto.Wallet{}
+		if err := json.Unmarshal([]byte(walletJSON), wallet); err != nil {
+			return configError("Error: invalid wallet at path:" + path)
+		}
+		if len(wallet.Mnemonic) == 0 {
+			return commandError("Error: the wallet at " + path + " has no mnemonic")
+		}
+
+		if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+			ok, err := promptConfirm("The mnemonic gives full control over the wallet and its tokens.\nType yes to print it: ", "yes")
+			if err != nil {
+				return commandError("Error:", err, "- use --yes to export without confirmation")
+			}
+			if !ok {
+				return commandError("Export canceled")
+			}
+		}
+
+		encScheme := encryption.NewEncryptionScheme()
+		if _, err := encScheme.Initialize(wallet.Mnemonic); err != nil {
+			return commandError("Error getting the public key for encryption.", err)
+		}
+		encPubKey, err := encScheme.GetPublicKey()
+		if err != nil {
+			return commandError("Error getting the public key for encryption.", err)
+		}
+
+		j := map[string]string{
+			"client_id":             wallet.ClientID,
+			"client_public_key":     wallet.ClientKey,
+			"encryption_public_key": encPubKey,
+			"mnemonic":              wallet.Mnemonic,
+		}
+		header := []string{"client_id", "client_public_key", "encryption_public_key", "mnemonic"}
+		rows := [][]string{{wallet.ClientID, wallet.ClientKey, encPubKey, wallet.Mnemonic}}
+		printOutput(cmd, j, header, rows, func() {
+			util.WriteTable(os.Stdout, []string{"Public Key", "ClientID", "Encryption Public Key"}, []string{},
+				[][]string{{wallet.ClientKey, wallet.ClientID, encPubKey}})
+			fmt.Println("Mnemonic:", wallet.Mnemonic)
+		})
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(walletCmd)
+	walletCmd.AddCommand(walletCreateCmd)
+	walletCmd.AddCommand(walletEncryptFileCmd)
+	walletCmd.AddCommand(walletDecryptFileCmd)
+	walletCmd.AddCommand(walletRecoverCmd)
+	walletCmd.AddCommand(walletExportCmd)
+
+	walletCreateCmd.Flags().Bool("encrypt", false, "encrypt the created wallet with a passphrase")
+	walletCreateCmd.Flags().Bool("read_pool", false, "create the read pool of the wallet, needed to download files")
+
+	walletRecoverCmd.Flags().String("mnemonic", "", "mnemonic of the wallet, prompted for if not set")
+	walletRecoverCmd.Flags().Bool("encrypt", false, "encrypt the recovered wallet with a passphrase")
+
+	walletExportCmd.Flags().Bool("yes", false, "print the mnemonic without asking for confirmation")
+}
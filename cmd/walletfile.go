@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// walletPassphraseEnv is the environment variable a wallet passphrase is read from when --wallet_passphrase_fd is not set
+const walletPassphraseEnv = "ZBOX_WALLET_PASSPHRASE"
+
+// walletPassphraseFD is the --wallet_passphrase_fd flag, -1 if not set
+var walletPassphraseFD int
+
+// walletPassphrase is the passphrase once it was read, as a file descriptor can only be read once
+var walletPassphrase string
+
+// scrypt parameters of newly encrypted wallets, the parameters a wallet was encrypted with are saved in its file
+const (
+	walletScryptN      = 1 << 15
+	walletScryptR      = 8
+	walletScryptP      = 1
+	walletKeyLen       = 32
+	walletSaltLen      = 32
+	walletCipherAESGCM = "aes-256-gcm"
+	walletKDFScrypt    = "scrypt"
+)
+
+// encryptedWallet is the content of an encrypted wallet file.
+// The wallet json is encrypted with AES-256-GCM, by a key derived from the passphrase with scrypt.
+type encryptedWallet struct {
+	Version    int             `json:"version"`
+	KDF        string          `json:"kdf"`
+	KDFParams  walletKDFParams `json:"kdf_params"`
+	Cipher     string          `json:"cipher"`
+	Nonce      string          `json:"nonce"`
+	Ciphertext string          `json:"ciphertext"`
+}
+
+type walletKDFParams struct {
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt string `json:"salt"`
+}
+
+// isEncryptedWallet reports if data is the content of an encrypted wallet file, and not a plain wallet json
+func isEncryptedWallet(data []byte) bool {
+	var w encryptedWallet
+	return json.Unmarshal(data, &w) == nil && len(w.KDF) > 0 && len(w.Ciphertext) > 0
+}
+
+func encryptWallet(walletJSON, passphrase string) ([]byte, error) {
+	salt := make([]byte, walletSaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	params := walletKDFParams{N: walletScryptN, R: walletScryptR, P: walletScryptP, Salt: hex.EncodeToString(salt)}
+	gcm, err := walletCipher(passphrase, params, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(&encryptedWallet{
+		Version:    1,
+		KDF:        walletKDFScrypt,
+		KDFParams:  params,
+		Cipher:     walletCipherAESGCM,
+		Nonce:      hex.EncodeToString(nonce),
+		Ciphertext: hex.EncodeToString(gcm.Seal(nil, nonce, []byte(walletJSON), nil)),
+	}, "", "  ")
+}
+
+func decryptWallet(data []byte, passphrase string) (string, error) {
+	var w encryptedWallet
+	if err := json.Unmarshal(data, &w); err != nil {
+		return "", err
+	}
+	if w.KDF != walletKDFScrypt || w.Cipher != walletCipherAESGCM {
+		return "", fmt.Errorf("unsupported wallet encryption %s/%s", w.KDF, w.Cipher)
+	}
+	salt, err := hex.DecodeString(w.KDFParams.Salt)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := hex.DecodeString(w.Nonce)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := hex.DecodeString(w.Ciphertext)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := walletCipher(passphrase, w.KDFParams, salt)
+	if err != nil {
+		return "", err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return "", errors.New("invalid wallet nonce")
+	}
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("wrong passphrase or corrupted wallet")
+	}
+	return string(plain), nil
+}
+
+func walletCipher(passphrase string, params walletKDFParams, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, params.N, params.R, params.P, walletKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readWalletFile returns the wallet json of the wallet file at path, decrypted in memory if the file is encrypted
+func readWalletFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return decodeWallet(data, path)
+}
+
+// decodeWallet returns the wallet json of data, decrypted if it is an encrypted wallet. name is where data was read from.
+func decodeWallet(data []byte, name string) (string, error) {
+	if !isEncryptedWallet(data) {
+		return string(data), nil
+	}
+	passphrase, err := getWalletPassphrase("Passphrase of "+name+": ", false)
+	if err != nil {
+		return "", err
+	}
+	return decryptWallet(data, passphrase)
+}
+
+// writeWalletFile saves walletJSON to path, encrypted if passphrase is not empty.
+// The file is replaced at once, so a failed write does not lose the wallet it replaces.
+func writeWalletFile(path, walletJSON, passphrase string) error {
+	data := []byte(walletJSON)
+	if len(passphrase) > 0 {
+		var err error
+		if data, err = encryptWallet(walletJSON, passphrase); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// walletPassphraseSupplied reports if a passphrase is given by --wallet_passphrase_fd or ZBOX_WALLET_PASSPHRASE,
+// which makes newly created wallets encrypted
+func walletPassphraseSupplied() bool {
+	return walletPassphraseFD >= 0 || len(os.Getenv(walletPassphraseEnv)) > 0
+}
+
+// getWalletPassphrase reads the passphrase from --wallet_passphrase_fd, ZBOX_WALLET_PASSPHRASE, or else prompts for it.
+// A prompted new passphrase (confirm) is asked twice.
+func getWalletPassphrase(prompt string, confirm bool) (string, error) {
+	if len(walletPassphrase) > 0 {
+		return walletPassphrase, nil
+	}
+
+	var passphrase string
+	switch {
+	case walletPassphraseFD >= 0:
+		f := os.NewFile(uintptr(walletPassphraseFD), "wallet passphrase")
+		if f == nil {
+			return "", fmt.Errorf("invalid wallet passphrase file descriptor %d", walletPassphraseFD)
+		}
+		line, err := bufio.NewReader(f).ReadString('\n')
+		f.Close()
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("reading wallet passphrase from file descriptor %d: %v", walletPassphraseFD, err)
+		}
+		passphrase = strings.TrimRight(line, "\r\n")
+	case len(os.Getenv(walletPassphraseEnv)) > 0:
+		passphrase = os.Getenv(walletPassphraseEnv)
+	default:
+		var err error
+		if passphrase, err = promptPassphrase(prompt); err != nil {
+			return "", err
+		}
+		if confirm {
+			again, err := promptPassphrase("Repeat passphrase: ")
+			if err != nil {
+				return "", err
+			}
+			if again != passphrase {
+				return "", errors.New("passphrases do not match")
+			}
+		}
+	}
+
+	if len(passphrase) == 0 {
+		return "", errors.New("empty wallet passphrase")
+	}
+	walletPassphrase = passphrase
+	return passphrase, nil
+}
+
+// promptPassphrase reads a passphrase from the terminal without echoing it, the prompt is printed on stderr
+func promptPassphrase(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("a wallet passphrase is needed, set %s or --wallet_passphrase_fd", walletPassphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	buf, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testWalletJSON = `{"client_id":"client","client_key":"key"}`
+
+// testEncryptedWallet is testWalletJSON encrypted with the passphrase "correct horse battery staple".
+// Wallets encrypted by earlier versions must still decrypt.
+const testEncryptedWallet = `{
+  "version": 1,
+  "kdf": "scrypt",
+  "kdf_params": {
+    "n": 32768,
+    "r": 8,
+    "p": 1,
+    "salt": "10059ac419a2dbdd829c2154a37813513a29f8969c080f61d67cb0dc11b3a9a5"
+  },
+  "cipher": "aes-256-gcm",
+  "nonce": "0ec3b5fba58a3deb38fdf293",
+  "ciphertext": "a5501000908e235515d27e6017f572a2a1439f6d4e08ca3b5666d8e9b38a0907333cba2a3e956e61299de4afadd916f6ec4805b5a54f4f063b"
+}`
+
+func TestEncryptWalletRoundTrip(t *testing.T) {
+	data, err := encryptWallet(testWalletJSON, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEncryptedWallet(data) || isEncryptedWallet([]byte(testWalletJSON)) {
+		t.Fatal("isEncryptedWallet does not tell encrypted and plain wallets apart")
+	}
+	if strings.Contains(string(data), "client_key") {
+		t.Fatal("the encrypted wallet holds the plain wallet")
+	}
+	got, err := decryptWallet(data, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != testWalletJSON {
+		t.Errorf("decrypted wallet is %s, want %s", got, testWalletJSON)
+	}
+
+	// the salt and nonce are random, so the same wallet encrypts differently every time
+	again, err := encryptWallet(testWalletJSON, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) == string(data) {
+		t.Error("the wallet encrypted twice is the same")
+	}
+}
+
+func TestDecryptWalletFormat(t *testing.T) {
+	got, err := decryptWallet([]byte(testEncryptedWallet), "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != testWalletJSON {
+		t.Errorf("decrypted wallet is %s, want %s", got, testWalletJSON)
+	}
+}
+
+func TestDecryptWalletErrors(t *testing.T) {
+	var w encryptedWallet
+	if err := json.Unmarshal([]byte(testEncryptedWallet), &w); err != nil {
+		t.Fatal(err)
+	}
+	encode := func(change func(w *encryptedWallet)) []byte {
+		c := w
+		change(&c)
+		data, _ := json.Marshal(&c)
+		return data
+	}
+	ciphertext, _ := hex.DecodeString(w.Ciphertext)
+	ciphertext[0] ^= 1
+
+	tests := []struct {
+		name       string
+		data       []byte
+		passphrase string
+		want       string
+	}{
+		{"wrong passphrase", []byte(testEncryptedWallet), "wrong horse battery staple", "wrong passphrase"},
+		{"empty passphrase", []byte(testEncryptedWallet), "", "wrong passphrase"},
+		{"tampered ciphertext", encode(func(w *encryptedWallet) { w.Ciphertext = hex.EncodeToString(ciphertext) }), "correct horse battery staple", "wrong passphrase"},
+		{"tampered salt", encode(func(w *encryptedWallet) { w.KDFParams.Salt = strings.Repeat("00", walletSaltLen) }), "correct horse battery staple", "wrong passphrase"},
+		{"unknown kdf", encode(func(w *encryptedWallet) { w.KDF = "pbkdf2" }), "correct horse battery staple", "unsupported wallet encryption"},
+		{"unknown cipher", encode(func(w *encryptedWallet) { w.Cipher = "aes-128-cbc" }), "correct horse battery staple", "unsupported wallet encryption"},
+		{"short nonce", encode(func(w *encryptedWallet) { w.Nonce = "00" }), "correct horse battery staple", "invalid wallet nonce"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decryptWallet(tt.data, tt.passphrase)
+			if err == nil {
+				t.Fatalf("decrypted %s", got)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error is %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteWalletFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallet.json")
+	if err := writeWalletFile(path, testWalletJSON, "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("wallet file mode is %v, want 0600", fi.Mode().Perm())
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEncryptedWallet(data) {
+		t.Fatal("the wallet file is not encrypted")
+	}
+
+	// readWalletFile takes the passphrase given once per command
+	defer func(passphrase string) { walletPassphrase = passphrase }(walletPassphrase)
+	walletPassphrase = "passphrase"
+	got, err := readWalletFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != testWalletJSON {
+		t.Errorf("read wallet is %s, want %s", got, testWalletJSON)
+	}
+
+	if err := writeWalletFile(path, testWalletJSON, ""); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != testWalletJSON {
+		t.Errorf("wallet written without a passphrase is %s", data)
+	}
+}
@@ -14,11 +14,12 @@ import (
 )
 
 var walletDecryptCmd = &cobra.Command{
-	Use:   "decrypt",
-	Short: "Decrypt text with passphase",
-	Long:  `Decrypt text with passphase`,
-	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:         "decrypt",
+	Short:       "Decrypt text with passphase",
+	Long:        `Decrypt text with passphase`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Args:        cobra.MinimumNArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		passphase, _ := cmd.Flags().GetString("passphase")
 		text, _ := cmd.Flags().GetString("text")
 
@@ -28,7 +29,7 @@ var walletDecryptCmd = &cobra.Command{
 		}
 
 		fmt.Println(decrypted)
-		return
+		return nil
 	},
 }
 
@@ -38,27 +39,22 @@ var walletinfoCmd = &cobra.Command{
 	Short: "Get wallet information",
 	Long:  `Get wallet information`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
-		doJSON, _ := cmd.Flags().GetBool("json")
-
+	RunE: func(cmd *cobra.Command, args []string) error {
 		header := []string{"Public Key", "ClientID", "Encryption Public Key"}
 		data := make([][]string, 1)
 		encPubKey, err := sdk.GetClientEncryptedPublicKey()
 		if err != nil {
-			fmt.Println("Error getting the public key for encryption. ", err.Error())
-			return
+			return commandError("Error getting the public key for encryption.", err)
 		}
 		data[0] = []string{client.GetClientPublicKey(), client.GetClientID(), encPubKey}
-		if doJSON {
-			j := make(map[string]string)
-			j["client_public_key"] = client.GetClientPublicKey()
-			j["client_id"] = client.GetClientID()
-			j["encryption_public_key"] = encPubKey
-			util.PrintJSON(j)
-			return
-		}
-		util.WriteTable(os.Stdout, header, []string{}, data)
-		return
+		j := make(map[string]string)
+		j["client_public_key"] = client.GetClientPublicKey()
+		j["client_id"] = client.GetClientID()
+		j["encryption_public_key"] = encPubKey
+		printOutput(cmd, j, []string{"client_public_key", "client_id", "encryption_public_key"}, data, func() {
+			util.WriteTable(os.Stdout, header, []string{}, data)
+		})
+		return nil
 	},
 }
 
@@ -67,7 +63,7 @@ var signCmd = &cobra.Command{
 	Short: "Sign given data",
 	Long:  `Sign given data`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		data, _ := cmd.Flags().GetString("data")
 		if data == "" {
 			data = client.GetClientID()
@@ -76,17 +72,16 @@ var signCmd = &cobra.Command{
 		}
 		sign, err := client.Sign(data)
 		if err != nil {
-			fmt.Println("Error generating the signature. ", err.Error())
-			return
+			return commandError("Error generating the signature.", err)
 		}
 		fmt.Println("Signature : " + sign)
-		return
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(walletinfoCmd)
-	walletinfoCmd.Flags().Bool("json", false, "pass this option to print response as json data")
+	walletinfoCmd.Flags().Bool("json", false, "pass this option to print response as json data, same as --output json")
 
 	rootCmd.AddCommand(signCmd)
 	signCmd.Flags().String("data", "", "give data for signing, Default will be clientID")
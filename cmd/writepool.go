@@ -2,22 +2,21 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/0chain/gosdk/zboxcore/sdk"
-	"github.com/0chain/zboxcli/util"
 	"github.com/0chain/gosdk/zcncore"
 	"github.com/spf13/cobra"
 )
 
 // wpInfo information
 var wpInfo = &cobra.Command{
-	Use:   "wp-info",
-	Short: "Write pool information.",
-	Long:  `Write pool information.`,
-	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:         "wp-info",
+	Short:       "Write pool information.",
+	Long:        `Write pool information.`,
+	Annotations: map[string]string{annotationAllocationFilter: "true"},
+	Args:        cobra.MinimumNArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags   = cmd.Flags()
@@ -27,26 +26,24 @@ var wpInfo = &cobra.Command{
 
 		if flags.Changed("allocation") {
 			if allocID, err = flags.GetString("allocation"); err != nil {
-				log.Fatalf("can't get 'allocation' flag: %v", err)
+				return usageError("can't get 'allocation' flag:", err)
 			}
 		}
-		doJSON, _ := cmd.Flags().GetBool("json")
 
 		var info *sdk.AllocationPoolStats
 		if info, err = sdk.GetWritePoolInfo(""); err != nil {
-			log.Fatalf("Failed to get write pool info: %v", err)
+			return commandError("Failed to get write pool info:", err)
 		}
-		if len(info.Pools) == 0 {
+		if len(info.Pools) == 0 && getOutputFormat(cmd) == outputTable {
 			fmt.Println("no tokens locked")
-			return
+			return nil
 		}
 
 		info.AllocFilter(allocID)
-		if doJSON {
-			util.PrintJSON(info.Pools)
-			return
-		}
-		printReadPoolStat(info.Pools)
+		printOutput(cmd, info.Pools, allocationPoolCSVHeader, allocationPoolCSVData(info.Pools), func() {
+			printReadPoolStat(info.Pools)
+		})
+		return nil
 	},
 }
 
@@ -56,7 +53,7 @@ var wpLock = &cobra.Command{
 	Short: "Lock some tokens in write pool.",
 	Long:  `Lock some tokens in write pool.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags     = cmd.Flags()
@@ -69,47 +66,48 @@ var wpLock = &cobra.Command{
 		)
 
 		if !flags.Changed("duration") {
-			log.Fatal("missing required 'duration' flag")
+			return usageError("missing required 'duration' flag")
 		}
 
 		if !flags.Changed("allocation") {
-			log.Fatal("missing required 'allocation' flag")
+			return usageError("missing required 'allocation' flag")
 		}
 
 		if !flags.Changed("tokens") {
-			log.Fatal("missing required 'tokens' flag")
+			return usageError("missing required 'tokens' flag")
 		}
 
 		if duration, err = flags.GetDuration("duration"); err != nil {
-			log.Fatal("invalid 'duration' flag: ", err)
+			return usageError("invalid 'duration' flag: ", err)
 		}
 
 		if allocID, err = flags.GetString("allocation"); err != nil {
-			log.Fatal("invalid 'allocation' flag: ", err)
+			return usageError("invalid 'allocation' flag: ", err)
 		}
 
 		if flags.Changed("blobber") {
 			if blobberID, err = flags.GetString("blobber"); err != nil {
-				log.Fatal("invalid 'blobber' flag: ", err)
+				return usageError("invalid 'blobber' flag: ", err)
 			}
 		}
 
 		if tokens, err = flags.GetFloat64("tokens"); err != nil {
-			log.Fatal("invalid 'tokens' flag: ", err)
+			return usageError("invalid 'tokens' flag: ", err)
 		}
 
 		if flags.Changed("fee") {
 			if fee, err = flags.GetFloat64("fee"); err != nil {
-				log.Fatal("invalid 'fee' flag: ", err)
+				return usageError("invalid 'fee' flag: ", err)
 			}
 		}
 
 		err = sdk.WritePoolLock(duration, allocID, blobberID,
 			zcncore.ConvertToValue(tokens), zcncore.ConvertToValue(fee))
 		if err != nil {
-			log.Fatalf("Failed to lock tokens in write pool: %v", err)
+			return commandError("Failed to lock tokens in write pool:", err)
 		}
 		fmt.Println("locked")
+		return nil
 	},
 }
 
@@ -119,7 +117,7 @@ var wpUnlock = &cobra.Command{
 	Short: "Unlock some expired tokens in a write pool.",
 	Long:  `Unlock some expired tokens in a write pool.`,
 	Args:  cobra.MinimumNArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var (
 			flags  = cmd.Flags()
@@ -129,24 +127,25 @@ var wpUnlock = &cobra.Command{
 		)
 
 		if !flags.Changed("pool_id") {
-			log.Fatal("missing required 'pool_id' flag")
+			return usageError("missing required 'pool_id' flag")
 		}
 
 		if poolID, err = flags.GetString("pool_id"); err != nil {
-			log.Fatal("invalid 'pool_id' flag: ", err)
+			return usageError("invalid 'pool_id' flag: ", err)
 		}
 
 		if flags.Changed("fee") {
 			if fee, err = flags.GetFloat64("fee"); err != nil {
-				log.Fatal("invalid 'fee' flag: ", err)
+				return usageError("invalid 'fee' flag: ", err)
 			}
 		}
 
 		err = sdk.WritePoolUnlock(poolID, zcncore.ConvertToValue(fee))
 		if err != nil {
-			log.Fatalf("Failed to unlock tokens in write pool: %v", err)
+			return commandError("Failed to unlock tokens in write pool:", err)
 		}
 		fmt.Println("unlocked")
+		return nil
 	},
 }
 
@@ -157,8 +156,7 @@ func init() {
 
 	wpInfo.PersistentFlags().String("allocation", "",
 		"allocation, optional")
-	wpInfo.Flags().Bool("json", false, "pass this option to print response as json data")
-
+	wpInfo.Flags().Bool("json", false, "pass this option to print response as json data, same as --output json")
 
 	wpLock.PersistentFlags().Duration("duration", 0,
 		"lock duration, required")
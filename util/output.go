@@ -0,0 +1,68 @@
+package util
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"gopkg.in/yaml.v2"
+)
+
+// PrintYAML prints v as yaml, with the same field names as PrintJSON
+func PrintYAML(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Fatalf("Failed to convert data to yaml format : %v", err)
+	}
+
+	// go through json, so json tags of sdk types name the fields
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err = decoder.Decode(&generic); err != nil {
+		log.Fatalf("Failed to convert data to yaml format : %v", err)
+	}
+
+	out, err := yaml.Marshal(jsonNumbers(generic))
+	if err != nil {
+		log.Fatalf("Failed to convert data to yaml format : %v", err)
+	}
+	fmt.Print(string(out))
+}
+
+// jsonNumbers converts json.Number values, which yaml would quote as strings, back to numbers
+func jsonNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = jsonNumbers(val)
+		}
+	case []interface{}:
+		for i, val := range t {
+			t[i] = jsonNumbers(val)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+		return t.String()
+	}
+	return v
+}
+
+//WriteCSV - Writes string data as csv with a header line
+func WriteCSV(writer io.Writer, header []string, data [][]string) {
+	w := csv.NewWriter(writer)
+	if err := w.Write(header); err != nil {
+		log.Fatalf("Failed to convert data to csv format : %v", err)
+	}
+	if err := w.WriteAll(data); err != nil {
+		log.Fatalf("Failed to convert data to csv format : %v", err)
+	}
+}